@@ -1,24 +1,44 @@
 package main
 
 import (
+	"expvar"
 	"github.com/jmoiron/sqlx"
 	"log"
+	"net/http"
 	"os"
+	"social-media-application/internal/audit"
+	"social-media-application/internal/block"
 	"social-media-application/internal/comment"
 	cr "social-media-application/internal/comment/reaction"
 	"social-media-application/internal/emoji"
+	"social-media-application/internal/follow"
+	"social-media-application/internal/friendship"
+	"social-media-application/internal/lockout"
+	"social-media-application/internal/mailer"
+	"social-media-application/internal/mute"
+	ott "social-media-application/internal/one_time_token"
+	"social-media-application/internal/password_reset"
+	"social-media-application/internal/personal_access_token"
 	"social-media-application/internal/post"
 	pr "social-media-application/internal/post/reaction"
 	"social-media-application/internal/refresh"
+	"social-media-application/internal/role"
+	"social-media-application/internal/social_login/core"
 	"social-media-application/internal/social_login/provider/facebook"
 	"social-media-application/internal/social_login/provider/google"
 	"social-media-application/internal/social_login/provider/microsoft"
+	"social-media-application/internal/social_login/provider/oidc"
 	"social-media-application/internal/social_login/provider_type"
 	"social-media-application/internal/social_login/social_user"
+	"social-media-application/internal/totp"
 	"social-media-application/internal/user"
+	pd "social-media-application/internal/user/password"
+	"social-media-application/internal/user/verification"
+	"social-media-application/internal/webauthn"
 	mw "social-media-application/middlewares"
 	"social-media-application/utils"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -75,25 +95,135 @@ func main() {
 	// Initialize middlewares
 	r.Use(mw.SecurityHeaders)
 	r.Use(mw.Cors())
+	r.Use(mw.CSRF)
+
+	// Initialize JWT signing keys, other services verify the access tokens with the published public keys
+	err = mw.InitKeyring()
+	if err != nil {
+		log.Fatal("can't initialize jwt keyring " + err.Error())
+		return
+	}
+	r.GET("/.well-known/jwks.json", mw.JWKS)
+
+	// Initialize rate limiter, requests are counted per user when logged in and per ip otherwise
+	// routes that can be brute forced are always counted per ip
+	rateLimiter := mw.NewRateLimiter(mw.NewRateLimitStore(os.Getenv("RATE_LIMIT_DRIVER")), mw.RateLimitPolicy{Name: "default", Limit: 300, Window: time.Minute}).
+		Route(http.MethodPost, "/users/login", mw.RateLimitPolicy{Name: "login", Limit: 10, Window: time.Minute, PerIp: true}).
+		Route(http.MethodPost, "/users/posts", mw.RateLimitPolicy{Name: "create-post", Limit: 10, Window: time.Minute}).
+		Route(http.MethodPost, "/users/totp/verify", mw.RateLimitPolicy{Name: "totp-verify", Limit: 10, Window: time.Minute, PerIp: true}).
+		Group("/users/password", mw.RateLimitPolicy{Name: "password", Limit: 5, Window: time.Minute, PerIp: true}).
+		Group("/users/verification", mw.RateLimitPolicy{Name: "verification", Limit: 5, Window: time.Minute, PerIp: true}).
+		Group("/users/webauthn/login", mw.RateLimitPolicy{Name: "webauthn-login", Limit: 20, Window: time.Minute, PerIp: true})
+	r.Use(rateLimiter.Handler)
 
 	// Initialize provider type module
 	providerRepository := provider_type.NewRepository(db)
 	providerService := provider_type.NewService(providerRepository)
 
+	// Initialize role module
+	roleRepository := role.NewRepository(db)
+	roleService := role.NewService(roleRepository)
+	roleController := role.NewController(roleService)
+	roleController.RegisterRoutes(r)
+
+	// Initialize audit module, it's used by the user, refresh token, two factor, and passkey modules
+	auditRepository := audit.NewRepository(db)
+	auditService := audit.NewService(auditRepository)
+	auditController := audit.NewController(auditService)
+	auditController.RegisterRoutes(r)
+
 	// Initialize refresh token module
 	refreshRepository := refresh.NewRepository(db)
-	refreshService := refresh.NewService(refreshRepository)
-	refreshController := refresh.NewController(refreshService)
+	refreshService := refresh.NewService(refreshRepository, auditService)
+	refreshController := refresh.NewController(refreshService, roleService)
 	refreshController.RegisterRoutes(r)
 
+	// Initialize mailer
+	appMailer := mailer.New(os.Getenv("MAIL_DRIVER"))
+
+	// Initialize email verification module
+	verificationTokenRepository := ott.NewRepository(db, "email_verification")
+	verificationTokenService := ott.NewService(verificationTokenRepository)
+	verificationService := verification.NewService(verificationTokenService, appMailer)
+
+	// Initialize two factor authentication module
+	totpRepository := totp.NewRepository(db)
+	totpService := totp.NewService(totpRepository, auditService)
+	totpController := totp.NewController(totpService, refreshService, roleService)
+	totpController.RegisterRoutes(r)
+
+	// Initialize login brute force protection, failed attempts are shared between instances with the redis driver
+	loginGuard := lockout.NewGuard(lockout.NewStore(os.Getenv("LOGIN_LOCKOUT_DRIVER")), lockout.PolicyFromEnv())
+	r.GET("/debug/vars", mw.JWT, mw.HasPermission(role.USERS_MANAGE), gin.WrapH(expvar.Handler()))
+
+	// Initialize password hashing, existing hashes are upgraded on login when the algorithm or cost changes
+	passwordHasher, err := pd.HasherFromEnv()
+	if err != nil {
+		log.Fatal("can't initialize password hasher " + err.Error())
+		return
+	}
+	passwordPolicy := pd.PolicyFromEnv(passwordHasher)
+
+	// Initialize personal access token module
+	personalAccessTokenRepository := personal_access_token.NewRepository(db)
+	personalAccessTokenService := personal_access_token.NewService(personalAccessTokenRepository)
+	personalAccessTokenController := personal_access_token.NewController(personalAccessTokenService)
+	personalAccessTokenController.RegisterRoutes(r)
+	mw.SetPersonalAccessTokenAuthenticator(personalAccessTokenService)
+
 	// Initialize user module
 	userRepository := user.NewRepository(db)
-	userService := user.NewService(userRepository)
-	userController := user.NewController(userService, refreshService)
+	userService := user.NewService(userRepository, verificationService, auditService, passwordHasher, passwordPolicy)
+	userController := user.NewController(userService, refreshService, personalAccessTokenService, roleService, totpService, loginGuard)
 	userController.RegisterRoutes(r)
 
+	// Initialize passkey module
+	webAuthn, err := webauthn.InitWebAuthn()
+	if err != nil {
+		log.Fatal("can't initialize webauthn " + err.Error())
+		return
+	}
+	webAuthnRepository := webauthn.NewRepository(db)
+	webAuthnService := webauthn.NewService(webAuthnRepository, userService, auditService, webAuthn)
+	webAuthnController := webauthn.NewController(webAuthnService, refreshService, roleService)
+	webAuthnController.RegisterRoutes(r)
+
+	// Initialize password reset module
+	passwordResetTokenRepository := ott.NewRepository(db, "password_reset")
+	passwordResetTokenService := ott.NewService(passwordResetTokenRepository)
+	passwordResetService := password_reset.NewService(passwordResetTokenService, userService, refreshService, personalAccessTokenService, appMailer, loginGuard, passwordPolicy)
+	passwordResetController := password_reset.NewController(passwordResetService)
+	passwordResetController.RegisterRoutes(r)
+
+	// Initialize linked social accounts module
 	userSocialRepository := social_user.NewRepository(db)
 	userSocialService := social_user.NewService(userSocialRepository)
+	userSocialController := social_user.NewController(userSocialService)
+	userSocialController.RegisterRoutes(r)
+
+	// Initialize block module
+	blockRepository := block.NewRepository(db)
+	blockService := block.NewService(blockRepository)
+	blockController := block.NewController(blockService)
+	blockController.RegisterRoutes(r)
+
+	// Initialize follow module
+	followRepository := follow.NewRepository(db)
+	followService := follow.NewService(followRepository, blockService)
+	followController := follow.NewController(followService)
+	followController.RegisterRoutes(r)
+
+	// Initialize friendship module
+	friendshipRepository := friendship.NewRepository(db)
+	friendshipService := friendship.NewService(friendshipRepository, userService, blockService)
+	friendshipController := friendship.NewController(friendshipService)
+	friendshipController.RegisterRoutes(r)
+
+	// Initialize mute module
+	muteRepository := mute.NewRepository(db)
+	muteService := mute.NewService(muteRepository)
+	muteController := mute.NewController(muteService)
+	muteController.RegisterRoutes(r)
 
 	// Initialize emoji module
 	emojiRepository := emoji.NewRepository(db)
@@ -103,42 +233,45 @@ func main() {
 
 	// Initialize post module
 	postRepository := post.NewRepository(db)
-	postService := post.NewService(postRepository)
+	postService := post.NewService(postRepository, blockService)
 	postController := post.NewController(postService)
 	postController.RegisterRoutes(r)
 
 	// Initialize post reaction module
 	postReactionRepository := pr.NewRepository(db)
-	postReactionService := pr.NewService(postReactionRepository)
+	postReactionService := pr.NewService(postReactionRepository, postService)
 	postReactionController := pr.NewController(postReactionService)
 	postReactionController.RegisterRoutes(r)
 
 	// Initialize comment module
 	commentRepository := comment.NewRepository(db)
-	commentService := comment.NewService(commentRepository)
+	commentService := comment.NewService(commentRepository, postService, blockService)
 	commentController := comment.NewController(commentService)
 	commentController.RegisterRoutes(r)
 
 	// Initialize comment reaction module
 	commentReactionRepository := cr.NewRepository(db)
-	commentReactionService := cr.NewService(commentReactionRepository)
+	commentReactionService := cr.NewService(commentReactionRepository, commentService)
 	commentReactionController := cr.NewController(commentReactionService)
 	commentReactionController.RegisterRoutes(r)
 
-	// Initialize Microsoft Login
-	microsoftConfig := microsoft.InitMSLogin()
-	microsoftController := microsoft.NewController(microsoftConfig, refreshService, userSocialService, userService, providerService)
-	microsoftController.RegisterRoutes(r)
+	// Initialize social login core
+	socialAuthenticator := core.NewAuthenticator(refreshService, roleService, totpService)
 
-	// Initialize Google Login
-	googleConfig := google.InitGoogleLogin()
-	googleController := google.NewController(googleConfig, refreshService, userSocialService, userService, providerService)
-	googleController.RegisterRoutes(r)
+	// Initialize social logins, OpenID Connect providers are added with OIDC_PROVIDERS
+	socialProviders := []core.Provider{
+		microsoft.NewProvider(),
+		google.NewProvider(),
+		facebook.NewProvider(),
+	}
+	for _, config := range oidc.ConfigsFromEnv() {
+		socialProviders = append(socialProviders, oidc.NewProvider(config))
+	}
 
-	// Initialize Facebook Login
-	facebookConfig := facebook.InitFacebookLogin()
-	facebookController := facebook.NewController(facebookConfig, refreshService, userSocialService, userService, providerService)
-	facebookController.RegisterRoutes(r)
+	for _, provider := range socialProviders {
+		socialController := core.NewController(provider, userSocialService, userService, providerService, socialAuthenticator)
+		socialController.RegisterRoutes(r)
+	}
 
 	err = r.Run(os.Getenv("PORT"))
 	if err != nil {
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"social-media-application/internal/social_login/provider/oidc/oidctest"
+	"strings"
+)
+
+// Runs the mock OpenID Connect provider for local development
+// Configure the api with OIDC_PROVIDERS=MOCK and OIDC_MOCK_ISSUER=http://localhost:9000
+// and add MOCK to provider_type
+func main() {
+	addr := getEnv("MOCK_IDP_ADDR", ":9000")
+	user := oidctest.User{
+		Sub:           getEnv("MOCK_IDP_SUB", "mock-user-1"),
+		Email:         getEnv("MOCK_IDP_EMAIL", "mock.user@social-media.local"),
+		EmailVerified: true,
+		GivenName:     getEnv("MOCK_IDP_GIVEN_NAME", "Mock"),
+		FamilyName:    getEnv("MOCK_IDP_FAMILY_NAME", "User"),
+	}
+
+	log.Println("mock idp listening on", addr)
+	err := http.ListenAndServe(addr, oidctest.NewHandler(user))
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	value := os.Getenv(key)
+	if strings.TrimSpace(value) == "" {
+		return defaultValue
+	}
+
+	return value
+}
@@ -0,0 +1,114 @@
+package audit
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"social-media-application/middlewares"
+	"time"
+)
+
+// Actions
+const (
+	LOGIN                 = "login"
+	LOGIN_FAILED          = "login_failed"
+	REFRESH_TOKEN_REVOKED = "refresh_token_revoked"
+	REFRESH_TOKEN_REUSED  = "refresh_token_reused"
+	PASSWORD_CHANGED      = "password_changed"
+	PASSWORD_SET          = "password_set"
+	STATUS_CHANGED        = "status_changed"
+)
+
+var AllActions = []string{LOGIN, LOGIN_FAILED, REFRESH_TOKEN_REVOKED, REFRESH_TOKEN_REUSED, PASSWORD_CHANGED, PASSWORD_SET, STATUS_CHANGED}
+
+// Event is append only, the actor is who did it and the target is the user it was done to
+// both are empty for failed logins of unknown emails
+type Event struct {
+	Id        int           `json:"id" db:"id"`
+	CreatedAt time.Time     `json:"created_at" db:"created_at"`
+	Action    string        `json:"action" db:"action"`
+	ActorId   sql.NullInt64 `json:"actor_id" db:"actor_id"`
+	TargetId  sql.NullInt64 `json:"target_id" db:"target_id"`
+	IpAddress string        `json:"ip_address" db:"ip_address"`
+	UserAgent string        `json:"user_agent" db:"user_agent"`
+	Metadata  Metadata      `json:"metadata" db:"metadata"`
+}
+
+// Origin is who made the request and from where
+type Origin struct {
+	ActorId   int
+	IpAddress string
+	UserAgent string
+}
+
+// NewOrigin the actor is the logged-in user, it's empty for public routes like login
+func NewOrigin(ctx *gin.Context) Origin {
+	actorId, _ := middleware.GetSubject(ctx)
+
+	return Origin{
+		ActorId:   actorId,
+		IpAddress: ctx.ClientIP(),
+		UserAgent: truncate(ctx.Request.UserAgent(), 255),
+	}
+}
+
+// As is used when the actor is only known after the request is checked like a password reset token
+func (o Origin) As(actorId int) Origin {
+	o.ActorId = actorId
+	return o
+}
+
+// Filter empty fields are not filtered
+type Filter struct {
+	Action    string
+	ActorId   int
+	TargetId  int
+	IpAddress string
+	From      time.Time
+	To        time.Time
+}
+
+// Metadata is saved as JSON
+type Metadata map[string]any
+
+func (m Metadata) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	bytes, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(bytes), nil
+}
+
+func (m *Metadata) Scan(value any) error {
+	var raw []byte
+	switch v := value.(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	case nil:
+		*m = Metadata{}
+		return nil
+	default:
+		return errors.New("metadata is not a string")
+	}
+
+	*m = Metadata{}
+	return json.Unmarshal(raw, m)
+}
+
+func truncate(value string, length int) string {
+	runes := []rune(value)
+	if len(runes) <= length {
+		return value
+	}
+
+	return string(runes[:length])
+}
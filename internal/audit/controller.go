@@ -0,0 +1,141 @@
+package audit
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"social-media-application/internal/paging"
+	"social-media-application/internal/role"
+	"social-media-application/middlewares"
+	"strconv"
+	"time"
+)
+
+type (
+	Controller interface {
+		getAll(ctx *gin.Context)
+		getAllBy(ctx *gin.Context)
+
+		RegisterRoutes(e *gin.Engine)
+	}
+
+	ControllerImpl struct {
+		service Service
+	}
+)
+
+func NewController(service Service) Controller {
+	return &ControllerImpl{
+		service: service,
+	}
+}
+
+func (c ControllerImpl) RegisterRoutes(e *gin.Engine) {
+	// audit:read
+	e.GET("/audit-events", middleware.JWT, middleware.HasPermission(role.AUDIT_READ), c.getAll)
+
+	// Protected
+	e.GET("/users/security-activity", middleware.JWT, c.getAllBy)
+}
+
+// getAll filters are action, actorId, targetId, ipAddress, and from and to in RFC 3339
+func (c ControllerImpl) getAll(ctx *gin.Context) {
+	filter := Filter{
+		Action:    ctx.Query("action"),
+		IpAddress: ctx.Query("ipAddress"),
+	}
+
+	var err error
+	if actorId := ctx.Query("actorId"); actorId != "" {
+		filter.ActorId, err = strconv.Atoi(actorId)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"message": "get all failed " + err.Error(),
+			})
+			return
+		}
+	}
+
+	if targetId := ctx.Query("targetId"); targetId != "" {
+		filter.TargetId, err = strconv.Atoi(targetId)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"message": "get all failed " + err.Error(),
+			})
+			return
+		}
+	}
+
+	if from := ctx.Query("from"); from != "" {
+		filter.From, err = time.Parse(time.RFC3339, from)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"message": "get all failed " + err.Error(),
+			})
+			return
+		}
+	}
+
+	if to := ctx.Query("to"); to != "" {
+		filter.To, err = time.Parse(time.RFC3339, to)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"message": "get all failed " + err.Error(),
+			})
+			return
+		}
+	}
+
+	request, err := pageRequestOf(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all failed " + err.Error(),
+		})
+		return
+	}
+
+	events, err := c.service.getAll(filter, request)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, events)
+}
+
+func (c ControllerImpl) getAllBy(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get security activity failed " + err.Error(),
+		})
+		return
+	}
+
+	request, err := pageRequestOf(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get security activity failed " + err.Error(),
+		})
+		return
+	}
+
+	events, err := c.service.getAllBy(sub, request)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "get security activity failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, events)
+}
+
+func pageRequestOf(ctx *gin.Context) (*paging.PageRequest, error) {
+	page := ctx.DefaultQuery("page", "1")
+	pageSize := ctx.DefaultQuery("pageSize", "10")
+	field := ctx.DefaultQuery("field", "created_at")
+	sortBy := ctx.DefaultQuery("sortBy", "DESC")
+	return paging.NewPageRequestStr(page, pageSize, field, sortBy)
+}
@@ -0,0 +1,112 @@
+package audit
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"log"
+	"social-media-application/internal/paging"
+	"social-media-application/utils"
+	"strings"
+)
+
+type (
+	// Repository has no update or delete so events cannot be changed once saved
+	Repository interface {
+		save(action string, actorId, targetId int, ipAddress, userAgent string, metadata Metadata) (id int64, err error)
+
+		findAll(filter Filter, request *paging.PageRequest) (*paging.Page[Event], error)
+	}
+
+	RepositoryImpl struct {
+		*sqlx.DB
+	}
+)
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &RepositoryImpl{
+		DB: db,
+	}
+}
+
+func (repository RepositoryImpl) save(action string, actorId, targetId int, ipAddress, userAgent string, metadata Metadata) (id int64, err error) {
+	result, err := repository.NamedExec("INSERT INTO audit_event (action, actor_id, target_id, ip_address, user_agent, metadata) VALUES (:action, NULLIF(:actorId, 0), NULLIF(:targetId, 0), :ipAddress, :userAgent, :metadata)", map[string]any{
+		"action":    action,
+		"actorId":   actorId,
+		"targetId":  targetId,
+		"ipAddress": ipAddress,
+		"userAgent": userAgent,
+		"metadata":  metadata,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	id, err = result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (repository RepositoryImpl) findAll(filter Filter, request *paging.PageRequest) (*paging.Page[Event], error) {
+	if !utils.IsInDBTag(request.Field, Event{}) {
+		request.Field = "created_at"
+		log.Println("WARNING: field is not in database! defaulted to", request.Field)
+	}
+
+	if !utils.IsInSortingOrder(request.SortBy) {
+		request.SortBy = "DESC"
+		log.Println("WARNING: sortBy is not valid! defaulted to", request.SortBy)
+	}
+
+	conditions := []string{"1 = 1"}
+	args := make([]any, 0)
+
+	if strings.TrimSpace(filter.Action) != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+
+	if filter.ActorId > 0 {
+		conditions = append(conditions, "actor_id = ?")
+		args = append(args, filter.ActorId)
+	}
+
+	if filter.TargetId > 0 {
+		conditions = append(conditions, "target_id = ?")
+		args = append(args, filter.TargetId)
+	}
+
+	if strings.TrimSpace(filter.IpAddress) != "" {
+		conditions = append(conditions, "ip_address = ?")
+		args = append(args, filter.IpAddress)
+	}
+
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.From)
+	}
+
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.To)
+	}
+
+	where := strings.Join(conditions, " AND ")
+
+	var total int
+	err := repository.Get(&total, "SELECT COUNT(*) FROM audit_event WHERE "+where, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]Event, 0, request.PageSize)
+	query := fmt.Sprintf("SELECT * FROM audit_event WHERE %s ORDER BY %s %s, id %s LIMIT ? OFFSET ?", where, request.Field, request.SortBy, request.SortBy)
+	err = repository.Select(&events, query, append(args, request.PageSize, request.Offset())...)
+	if err != nil {
+		return nil, err
+	}
+
+	return paging.NewPage(events, request, total), nil
+}
@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"errors"
+	"log"
+	"slices"
+	"social-media-application/internal/paging"
+	"strings"
+)
+
+type (
+	Service interface {
+		Record(action string, targetId int, origin Origin, metadata Metadata)
+
+		getAll(filter Filter, request *paging.PageRequest) (*paging.Page[Event], error)
+		getAllBy(userId int, request *paging.PageRequest) (*paging.Page[Event], error)
+	}
+
+	ServiceImpl struct {
+		repository Repository
+	}
+)
+
+func NewService(repository Repository) Service {
+	return &ServiceImpl{
+		repository: repository,
+	}
+}
+
+// Record does not return an error so a failed write never fails the login or change being recorded
+func (s ServiceImpl) Record(action string, targetId int, origin Origin, metadata Metadata) {
+	_, err := s.repository.save(action, origin.ActorId, targetId, origin.IpAddress, origin.UserAgent, metadata)
+	if err != nil {
+		log.Printf("WARNING: saving audit event %s of user %d failed %v", action, targetId, err)
+	}
+}
+
+func (s ServiceImpl) getAll(filter Filter, request *paging.PageRequest) (*paging.Page[Event], error) {
+	if strings.TrimSpace(filter.Action) != "" && !slices.Contains(AllActions, filter.Action) {
+		return nil, errors.New("action is invalid")
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, errors.New("from should be before to")
+	}
+
+	events, err := s.repository.findAll(filter, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// getAllBy is the recent security activity of the user
+func (s ServiceImpl) getAllBy(userId int, request *paging.PageRequest) (*paging.Page[Event], error) {
+	if userId <= 0 {
+		return nil, errors.New("user id is required")
+	}
+
+	events, err := s.repository.findAll(Filter{TargetId: userId}, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
@@ -0,0 +1,10 @@
+package block
+
+import "time"
+
+type Block struct {
+	Id        int       `json:"id" db:"id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	BlockerId int       `json:"blocker_id" db:"blocker_id"`
+	BlockedId int       `json:"blocked_id" db:"blocked_id"`
+}
@@ -0,0 +1,158 @@
+package block
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"log"
+	"social-media-application/internal/paging"
+	"social-media-application/utils"
+)
+
+type (
+	Repository interface {
+		save(blockerId, blockedId int) (id int64, err error)
+
+		findAll(blockerId int, request *paging.PageRequest) (*paging.Page[Block], error)
+
+		delete(blockerId, blockedId int) (affectedRows int64, err error)
+
+		isAlreadyBlocked(blockerId, blockedId int) (bool, error)
+		isBlocked(userId, otherUserId int) (bool, error)
+	}
+
+	RepositoryImpl struct {
+		*sqlx.DB
+	}
+)
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &RepositoryImpl{
+		DB: db,
+	}
+}
+
+// save also removes the follows and friendship between the two users
+// so the blocked user will not keep receiving the blocker's posts
+func (repository RepositoryImpl) save(blockerId, blockedId int) (id int64, err error) {
+	tx, err := repository.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer func(tx *sqlx.Tx) {
+		err := tx.Rollback()
+		if err != nil {
+			return
+		}
+	}(tx)
+
+	args := map[string]any{
+		"blockerId": blockerId,
+		"blockedId": blockedId,
+	}
+
+	result, err := tx.NamedExec("INSERT INTO block (blocker_id, blocked_id) VALUES (:blockerId, :blockedId)", args)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.NamedExec(`
+		DELETE FROM follow
+		WHERE (follower_id = :blockerId AND followee_id = :blockedId)
+		OR (follower_id = :blockedId AND followee_id = :blockerId)
+	`, args)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.NamedExec(`
+		DELETE FROM friendship
+		WHERE (requester_id = :blockerId AND addressee_id = :blockedId)
+		OR (requester_id = :blockedId AND addressee_id = :blockerId)
+	`, args)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err = result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (repository RepositoryImpl) findAll(blockerId int, request *paging.PageRequest) (*paging.Page[Block], error) {
+	if !utils.IsInDBTag(request.Field, Block{}) {
+		request.Field = "created_at"
+		log.Println("WARNING: field is not in database! defaulted to", request.Field)
+	}
+
+	if !utils.IsInSortingOrder(request.SortBy) {
+		request.SortBy = "DESC"
+		log.Println("WARNING: sortBy is not valid! defaulted to", request.SortBy)
+	}
+
+	var total int
+	err := repository.Get(&total, "SELECT COUNT(*) FROM block WHERE blocker_id = ?", blockerId)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := make([]Block, 0, request.PageSize)
+	query := fmt.Sprintf("SELECT * FROM block WHERE blocker_id = ? ORDER BY %s %s LIMIT ? OFFSET ?", request.Field, request.SortBy)
+	err = repository.Select(&blocks, query, blockerId, request.PageSize, request.Offset())
+	if err != nil {
+		return nil, err
+	}
+
+	return paging.NewPage(blocks, request, total), nil
+}
+
+func (repository RepositoryImpl) delete(blockerId, blockedId int) (affectedRows int64, err error) {
+	result, err := repository.NamedExec("DELETE FROM block WHERE blocker_id = :blockerId AND blocked_id = :blockedId", map[string]any{
+		"blockerId": blockerId,
+		"blockedId": blockedId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
+func (repository RepositoryImpl) isAlreadyBlocked(blockerId, blockedId int) (bool, error) {
+	var exists bool
+	err := repository.Get(&exists, "SELECT EXISTS(SELECT 1 FROM block WHERE blocker_id = ? AND blocked_id = ?)", blockerId, blockedId)
+	if err != nil {
+		return exists, err
+	}
+
+	return exists, nil
+}
+
+func (repository RepositoryImpl) isBlocked(userId, otherUserId int) (bool, error) {
+	var exists bool
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM block
+			WHERE (blocker_id = ? AND blocked_id = ?)
+			OR (blocker_id = ? AND blocked_id = ?)
+		)
+	`
+	err := repository.Get(&exists, query, userId, otherUserId, otherUserId, userId)
+	if err != nil {
+		return exists, err
+	}
+
+	return exists, nil
+}
@@ -0,0 +1,109 @@
+package block
+
+import (
+	"errors"
+	"social-media-application/internal/paging"
+)
+
+type (
+	Service interface {
+		save(blockerId, blockedId int) (id int64, err error)
+
+		getAll(blockerId int, request *paging.PageRequest) (*paging.Page[Block], error)
+
+		delete(blockerId, blockedId int) (affectedRows int64, err error)
+
+		IsBlocked(userId, otherUserId int) (bool, error) // either user blocked the other
+	}
+
+	ServiceImpl struct {
+		repository Repository
+	}
+)
+
+func NewService(repository Repository) Service {
+	return &ServiceImpl{
+		repository: repository,
+	}
+}
+
+func (s ServiceImpl) save(blockerId, blockedId int) (id int64, err error) {
+	if blockerId <= 0 {
+		return 0, errors.New("blocker id is required")
+	}
+
+	if blockedId <= 0 {
+		return 0, errors.New("blocked id is required")
+	}
+
+	if blockerId == blockedId {
+		return 0, errors.New("cannot block yourself")
+	}
+
+	isAlreadyBlocked, err := s.repository.isAlreadyBlocked(blockerId, blockedId)
+	if err != nil {
+		return 0, err
+	}
+
+	if isAlreadyBlocked {
+		return 0, errors.New("user already blocked")
+	}
+
+	id, err = s.repository.save(blockerId, blockedId)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (s ServiceImpl) getAll(blockerId int, request *paging.PageRequest) (*paging.Page[Block], error) {
+	if blockerId <= 0 {
+		return nil, errors.New("blocker id is required")
+	}
+
+	blocks, err := s.repository.findAll(blockerId, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return blocks, nil
+}
+
+func (s ServiceImpl) delete(blockerId, blockedId int) (affectedRows int64, err error) {
+	if blockerId <= 0 {
+		return 0, errors.New("blocker id is required")
+	}
+
+	if blockedId <= 0 {
+		return 0, errors.New("blocked id is required")
+	}
+
+	affectedRows, err = s.repository.delete(blockerId, blockedId)
+	if err != nil {
+		return 0, err
+	}
+
+	if affectedRows <= 0 {
+		return 0, errors.New("current user did not block this user")
+	}
+
+	return affectedRows, nil
+}
+
+func (s ServiceImpl) IsBlocked(userId, otherUserId int) (bool, error) {
+	if userId <= 0 {
+		return false, errors.New("user id is required")
+	}
+
+	if otherUserId <= 0 {
+		return false, errors.New("other user id is required")
+	}
+
+	isBlocked, err := s.repository.isBlocked(userId, otherUserId)
+	if err != nil {
+		return false, err
+	}
+
+	return isBlocked, nil
+}
@@ -13,4 +13,15 @@ type Comment struct {
 	IsDeleted  bool           `json:"-"  db:"is_deleted"`
 	AuthorId   int            `json:"author_id"  db:"author_id"`
 	PostId     int            `json:"post_id" db:"post_id"`
+	ParentId   sql.NullInt64  `json:"parent_id" db:"parent_id"`
+	Depth      int            `json:"depth" db:"depth"`
+	ReplyCount int            `json:"reply_count" db:"reply_count"` // computed, not a column
+	Summary    *Summary       `json:"summary,omitempty" db:"-"`
+}
+
+// Summary is only filled when requested with includeSummary
+type Summary struct {
+	TotalReactions int         `json:"total_reactions"`
+	Reactions      map[int]int `json:"reactions"`   // emoji id to count
+	MyReaction     *int        `json:"my_reaction"` // emoji id of the current user's reaction
 }
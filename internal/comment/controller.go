@@ -11,9 +11,11 @@ import (
 type (
 	Controller interface {
 		save(ctx *gin.Context)
+		saveReply(ctx *gin.Context)
 
 		getById(ctx *gin.Context)
 		getAll(ctx *gin.Context)
+		getAllReplies(ctx *gin.Context)
 
 		updateContent(ctx *gin.Context)
 		updateAttachment(ctx *gin.Context)
@@ -35,13 +37,16 @@ func NewController(service Service) Controller {
 }
 
 func (c ControllerImpl) RegisterRoutes(e *gin.Engine) {
-	r := e.Group("/users/posts/:id/comments", middleware.JWT)
+	r := e.Group("/users/posts/:id/comments", middleware.Scoped("comments"))
 	{
 		r.POST("", c.save)
 
 		r.GET("/:commentId", c.getById)
 		r.GET("", c.getAll)
 
+		r.POST("/:commentId/replies", c.saveReply)
+		r.GET("/:commentId/replies", c.getAllReplies)
+
 		r.PATCH("/:commentId/content", c.updateContent)
 		r.PATCH("/:commentId/attachment", c.updateAttachment)
 
@@ -88,7 +93,62 @@ func (c ControllerImpl) save(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, id)
 }
 
+func (c ControllerImpl) saveReply(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "save reply failed " + err.Error(),
+		})
+		return
+	}
+
+	postId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "save reply failed " + err.Error(),
+		})
+		return
+	}
+
+	parentId, err := strconv.Atoi(ctx.Param("commentId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "save reply failed " + err.Error(),
+		})
+		return
+	}
+
+	request := struct {
+		Content    string `json:"content" binding:"required"`
+		Attachment string `json:"attachment"`
+	}{}
+	if err := ctx.ShouldBind(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "save reply failed " + err.Error(),
+		})
+		return
+	}
+
+	id, err := c.service.saveReply(sub, postId, parentId, request.Content, request.Attachment)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "save reply failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, id)
+}
+
 func (c ControllerImpl) getById(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get by id failed " + err.Error(),
+		})
+		return
+	}
+
 	postId, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
@@ -105,17 +165,44 @@ func (c ControllerImpl) getById(ctx *gin.Context) {
 		return
 	}
 
-	comment, err := c.service.getById(postId, commentId)
+	includeSummary, err := strconv.ParseBool(ctx.DefaultQuery("includeSummary", "false"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get by id failed " + err.Error(),
+		})
+		return
+	}
+
+	comment, err := c.service.GetById(sub, postId, commentId)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "get by id failed " + err.Error(),
 		})
+		return
+	}
+
+	if includeSummary {
+		comment.Summary, err = c.service.getSummary(sub, comment.Id)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"message": "get by id failed " + err.Error(),
+			})
+			return
+		}
 	}
 
 	ctx.JSON(http.StatusOK, comment)
 }
 
 func (c ControllerImpl) getAll(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all failed " + err.Error(),
+		})
+		return
+	}
+
 	postId, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
@@ -132,6 +219,14 @@ func (c ControllerImpl) getAll(ctx *gin.Context) {
 		return
 	}
 
+	includeSummary, err := strconv.ParseBool(ctx.DefaultQuery("includeSummary", "false"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all failed " + err.Error(),
+		})
+		return
+	}
+
 	page := ctx.DefaultQuery("page", "1")
 	pageSize := ctx.DefaultQuery("pageSize", "10")
 	field := ctx.DefaultQuery("field", "created_at")
@@ -144,7 +239,7 @@ func (c ControllerImpl) getAll(ctx *gin.Context) {
 		return
 	}
 
-	comments, err := c.service.getAll(postId, isDeleted, request)
+	comments, err := c.service.getAll(sub, postId, isDeleted, includeSummary, request)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "get all failed " + err.Error(),
@@ -155,6 +250,71 @@ func (c ControllerImpl) getAll(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, comments)
 }
 
+func (c ControllerImpl) getAllReplies(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all replies failed " + err.Error(),
+		})
+		return
+	}
+
+	postId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all replies failed " + err.Error(),
+		})
+		return
+	}
+
+	parentId, err := strconv.Atoi(ctx.Param("commentId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all replies failed " + err.Error(),
+		})
+		return
+	}
+
+	isDeleted, err := strconv.ParseBool(ctx.DefaultQuery("isDeleted", "false"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all replies failed " + err.Error(),
+		})
+		return
+	}
+
+	includeSummary, err := strconv.ParseBool(ctx.DefaultQuery("includeSummary", "false"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all replies failed " + err.Error(),
+		})
+		return
+	}
+
+	// Replies read top to bottom so oldest first by default
+	page := ctx.DefaultQuery("page", "1")
+	pageSize := ctx.DefaultQuery("pageSize", "10")
+	field := ctx.DefaultQuery("field", "created_at")
+	sortBy := ctx.DefaultQuery("sortBy", "ASC")
+	request, err := paging.NewPageRequestStr(page, pageSize, field, sortBy)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all replies failed " + err.Error(),
+		})
+		return
+	}
+
+	replies, err := c.service.getAllReplies(sub, postId, parentId, isDeleted, includeSummary, request)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "get all replies failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, replies)
+}
+
 func (c ControllerImpl) updateContent(ctx *gin.Context) {
 	sub, err := middleware.GetSubject(ctx)
 	if err != nil {
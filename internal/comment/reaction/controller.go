@@ -1,6 +1,7 @@
 package commentreaction
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"social-media-application/internal/paging"
@@ -35,7 +36,7 @@ func NewController(service Service) Controller {
 }
 
 func (c ControllerImpl) RegisterRoutes(e *gin.Engine) {
-	r := e.Group("/users/posts/:id/comments/:commentId/reactions", middleware.JWT)
+	r := e.Group("/users/posts/:id/comments/:commentId/reactions", middleware.Scoped("reactions"))
 	{
 		r.POST("", c.save)
 
@@ -83,6 +84,13 @@ func (c ControllerImpl) save(ctx *gin.Context) {
 
 	id, err := c.service.save(sub, postId, commentId, emojiId)
 	if err != nil {
+		if errors.Is(err, ErrAlreadyReacted) {
+			ctx.JSON(http.StatusConflict, gin.H{
+				"message": "saved failed " + err.Error(),
+			})
+			return
+		}
+
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "saved failed " + err.Error(),
 		})
@@ -93,6 +101,14 @@ func (c ControllerImpl) save(ctx *gin.Context) {
 }
 
 func (c ControllerImpl) getById(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get by id failed " + err.Error(),
+		})
+		return
+	}
+
 	postId, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
@@ -117,7 +133,7 @@ func (c ControllerImpl) getById(ctx *gin.Context) {
 		return
 	}
 
-	reaction, err := c.service.getById(postId, commentId, reactionId)
+	reaction, err := c.service.getById(sub, postId, commentId, reactionId)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "get by id failed " + err.Error(),
@@ -129,6 +145,14 @@ func (c ControllerImpl) getById(ctx *gin.Context) {
 }
 
 func (c ControllerImpl) getAll(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all failed " + err.Error(),
+		})
+		return
+	}
+
 	postId, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
@@ -157,7 +181,7 @@ func (c ControllerImpl) getAll(ctx *gin.Context) {
 		return
 	}
 
-	reactions, err := c.service.getAll(postId, commentId, request)
+	reactions, err := c.service.getAll(sub, postId, commentId, request)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "get all failed " + err.Error(),
@@ -169,6 +193,14 @@ func (c ControllerImpl) getAll(ctx *gin.Context) {
 }
 
 func (c ControllerImpl) getAllByEmoji(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all by emoji failed " + err.Error(),
+		})
+		return
+	}
+
 	postId, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
@@ -205,7 +237,7 @@ func (c ControllerImpl) getAllByEmoji(ctx *gin.Context) {
 		return
 	}
 
-	reactions, err := c.service.getAllByEmoji(postId, commentId, emojiId, request)
+	reactions, err := c.service.getAllByEmoji(sub, postId, commentId, emojiId, request)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "get all by emoji failed " + err.Error(),
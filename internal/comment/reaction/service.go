@@ -2,16 +2,18 @@ package commentreaction
 
 import (
 	"errors"
+	"social-media-application/internal/comment"
 	"social-media-application/internal/paging"
+	"social-media-application/utils"
 )
 
 type (
 	Service interface {
 		save(reactorId, postId, commentId, emojiId int) (id int64, err error)
 
-		getById(postId, commentId, reactionId int) (Reaction, error)
-		getAll(postId, commentId int, request *paging.PageRequest) (*paging.Page[Reaction], error)
-		getAllByEmoji(postId, commentId, emojiId int, request *paging.PageRequest) (*paging.Page[Reaction], error)
+		getById(currentUserId, postId, commentId, reactionId int) (Reaction, error)
+		getAll(currentUserId, postId, commentId int, request *paging.PageRequest) (*paging.Page[Reaction], error)
+		getAllByEmoji(currentUserId, postId, commentId, emojiId int, request *paging.PageRequest) (*paging.Page[Reaction], error)
 
 		update(reactorId, postId, commentId, newEmojiId int) (affectedRows int64, err error)
 
@@ -19,13 +21,15 @@ type (
 	}
 
 	ServiceImpl struct {
-		repository Repository
+		repository     Repository
+		commentService comment.Service
 	}
 )
 
-func NewService(repository Repository) Service {
+func NewService(repository Repository, commentService comment.Service) Service {
 	return &ServiceImpl{
-		repository: repository,
+		repository:     repository,
+		commentService: commentService,
 	}
 }
 
@@ -42,24 +46,34 @@ func (s ServiceImpl) save(reactorId, postId, commentId, emojiId int) (id int64,
 		return 0, errors.New("emojiId is required")
 	}
 
+	_, err = s.commentService.GetById(reactorId, postId, commentId)
+	if err != nil {
+		return 0, err
+	}
+
 	isAlreadyReacted, err := s.repository.isAlreadyReacted(reactorId, postId, commentId)
 	if err != nil {
 		return 0, err
 	}
 
 	if isAlreadyReacted {
-		return 0, errors.New("reactor already reacted")
+		return 0, ErrAlreadyReacted
 	}
 
+	// Concurrent reactions can both pass the check above, the unique key rejects the later one
 	id, err = s.repository.save(reactorId, postId, commentId, emojiId)
 	if err != nil {
+		if utils.IsDuplicateEntry(err) {
+			return 0, ErrAlreadyReacted
+		}
+
 		return 0, err
 	}
 
 	return id, nil
 }
 
-func (s ServiceImpl) getById(postId, commentId, reactionId int) (Reaction, error) {
+func (s ServiceImpl) getById(currentUserId, postId, commentId, reactionId int) (Reaction, error) {
 	if postId <= 0 {
 		return Reaction{}, errors.New("postId is required")
 	}
@@ -72,6 +86,11 @@ func (s ServiceImpl) getById(postId, commentId, reactionId int) (Reaction, error
 		return Reaction{}, errors.New("reactionId is required")
 	}
 
+	_, err := s.commentService.GetById(currentUserId, postId, commentId)
+	if err != nil {
+		return Reaction{}, err
+	}
+
 	reaction, err := s.repository.findById(postId, commentId, reactionId)
 	if err != nil {
 		return Reaction{}, err
@@ -80,7 +99,7 @@ func (s ServiceImpl) getById(postId, commentId, reactionId int) (Reaction, error
 	return reaction, nil
 }
 
-func (s ServiceImpl) getAll(postId, commentId int, request *paging.PageRequest) (*paging.Page[Reaction], error) {
+func (s ServiceImpl) getAll(currentUserId, postId, commentId int, request *paging.PageRequest) (*paging.Page[Reaction], error) {
 	if postId <= 0 {
 		return nil, errors.New("postId is required")
 	}
@@ -89,6 +108,11 @@ func (s ServiceImpl) getAll(postId, commentId int, request *paging.PageRequest)
 		return nil, errors.New("commentId is required")
 	}
 
+	_, err := s.commentService.GetById(currentUserId, postId, commentId)
+	if err != nil {
+		return nil, err
+	}
+
 	reactions, err := s.repository.findAll(postId, commentId, request)
 	if err != nil {
 		return nil, err
@@ -97,7 +121,7 @@ func (s ServiceImpl) getAll(postId, commentId int, request *paging.PageRequest)
 	return reactions, nil
 }
 
-func (s ServiceImpl) getAllByEmoji(postId, commentId, emojiId int, request *paging.PageRequest) (*paging.Page[Reaction], error) {
+func (s ServiceImpl) getAllByEmoji(currentUserId, postId, commentId, emojiId int, request *paging.PageRequest) (*paging.Page[Reaction], error) {
 	if postId <= 0 {
 		return nil, errors.New("postId is required")
 	}
@@ -110,6 +134,11 @@ func (s ServiceImpl) getAllByEmoji(postId, commentId, emojiId int, request *pagi
 		return nil, errors.New("emojiId is required")
 	}
 
+	_, err := s.commentService.GetById(currentUserId, postId, commentId)
+	if err != nil {
+		return nil, err
+	}
+
 	reactions, err := s.repository.findAllByEmoji(postId, commentId, emojiId, request)
 	if err != nil {
 		return nil, err
@@ -135,6 +164,11 @@ func (s ServiceImpl) update(reactorId, postId, commentId, newEmojiId int) (affec
 		return 0, errors.New("emojiId is required")
 	}
 
+	_, err = s.commentService.GetById(reactorId, postId, commentId)
+	if err != nil {
+		return 0, err
+	}
+
 	isAlreadyReacted, err := s.repository.isAlreadyReacted(reactorId, postId, commentId)
 	if err != nil {
 		return 0, err
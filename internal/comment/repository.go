@@ -8,17 +8,35 @@ import (
 	"social-media-application/utils"
 )
 
+// excludeBlockedAndMuted hides comments of authors the current user blocked, was blocked by, or muted
+// Expects the current user id three times
+const excludeBlockedAndMuted = `
+	AND c.author_id NOT IN (SELECT blocked_id FROM block WHERE blocker_id = ?)
+	AND c.author_id NOT IN (SELECT blocker_id FROM block WHERE blocked_id = ?)
+	AND c.author_id NOT IN (SELECT muted_id FROM mute WHERE muter_id = ?)
+`
+
+// selectComment also counts the non deleted replies of each comment
+const selectComment = `
+	SELECT c.*, (SELECT COUNT(*) FROM comment r WHERE r.parent_id = c.id AND r.is_deleted = false) AS reply_count
+	FROM comment c
+`
+
 type (
 	Repository interface {
 		save(authorId, postId int, content, attachment string) (id int64, err error)
+		saveReply(authorId, postId, parentId, depth int, content, attachment string) (id int64, err error)
 
 		findById(postId, commentId int) (Comment, error)
-		findAll(postId int, isDeleted bool, request *paging.PageRequest) (*paging.Page[Comment], error)
+		findAll(currentUserId, postId int, isDeleted bool, request *paging.PageRequest) (*paging.Page[Comment], error)
+		findAllReplies(currentUserId, postId, parentId int, isDeleted bool, request *paging.PageRequest) (*paging.Page[Comment], error)
 
 		updateContent(currentUserId, postId, commentId int, newContent string) (affectedRows int64, err error)
 		updateAttachment(currentUserId, postId, commentId int, newAttachment string) (affectedRows int64, err error)
 
 		deleteById(currentUserId, postId, commentId int) (affectedRows int64, err error)
+
+		findSummaries(currentUserId int, commentIds []int) (map[int]*Summary, error)
 	}
 
 	RepositoryImpl struct {
@@ -51,9 +69,30 @@ func (repository RepositoryImpl) save(authorId, postId int, content, attachment
 	return id, nil
 }
 
+func (repository RepositoryImpl) saveReply(authorId, postId, parentId, depth int, content, attachment string) (id int64, err error) {
+	result, err := repository.NamedExec("INSERT INTO comment (author_id, post_id, parent_id, depth, content, attachment) VALUE (:authorId, :postId, :parentId, :depth, :content, :attachment)", map[string]any{
+		"authorId":   authorId,
+		"postId":     postId,
+		"parentId":   parentId,
+		"depth":      depth,
+		"content":    content,
+		"attachment": attachment,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	id, err = result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
 func (repository RepositoryImpl) findById(postId, commentId int) (Comment, error) {
 	var comment Comment
-	err := repository.Get(&comment, "SELECT * FROM comment WHERE post_id = ? AND id = ?", postId, commentId)
+	err := repository.Get(&comment, selectComment+"WHERE c.post_id = ? AND c.id = ?", postId, commentId)
 	if err != nil {
 		return Comment{}, err
 	}
@@ -61,7 +100,7 @@ func (repository RepositoryImpl) findById(postId, commentId int) (Comment, error
 	return comment, nil
 }
 
-func (repository RepositoryImpl) findAll(postId int, isDeleted bool, request *paging.PageRequest) (*paging.Page[Comment], error) {
+func (repository RepositoryImpl) findAll(currentUserId, postId int, isDeleted bool, request *paging.PageRequest) (*paging.Page[Comment], error) {
 	if !utils.IsInDBTag(request.Field, Comment{}) {
 		request.Field = "created_at"
 		log.Println("WARNING: field is not in database! defaulted to", request.Field)
@@ -73,11 +112,14 @@ func (repository RepositoryImpl) findAll(postId int, isDeleted bool, request *pa
 	}
 
 	var total int
-	err := repository.Get(&total, "SELECT COUNT(*) FROM comment WHERE post_id = ? AND is_deleted = ?", postId, isDeleted)
+	err := repository.Get(&total, "SELECT COUNT(*) FROM comment c WHERE c.post_id = ? AND c.parent_id IS NULL AND c.is_deleted = ?"+excludeBlockedAndMuted, postId, isDeleted, currentUserId, currentUserId, currentUserId)
+	if err != nil {
+		return nil, err
+	}
 
-	comments := make([]Comment, request.PageSize)
-	query := fmt.Sprintf("SELECT * FROM comment WHERE post_id = ? AND is_deleted = ? ORDER BY %s %s LIMIT ? OFFSET ?", request.Field, request.SortBy)
-	err = repository.Select(&comments, query, postId, isDeleted, request.PageSize, request.Offset())
+	comments := make([]Comment, 0, request.PageSize)
+	query := fmt.Sprintf("%s WHERE c.post_id = ? AND c.parent_id IS NULL AND c.is_deleted = ? %s ORDER BY %s %s LIMIT ? OFFSET ?", selectComment, excludeBlockedAndMuted, request.Field, request.SortBy)
+	err = repository.Select(&comments, query, postId, isDeleted, currentUserId, currentUserId, currentUserId, request.PageSize, request.Offset())
 	if err != nil {
 		return nil, err
 	}
@@ -85,6 +127,33 @@ func (repository RepositoryImpl) findAll(postId int, isDeleted bool, request *pa
 	return paging.NewPage(comments, request, total), nil
 }
 
+func (repository RepositoryImpl) findAllReplies(currentUserId, postId, parentId int, isDeleted bool, request *paging.PageRequest) (*paging.Page[Comment], error) {
+	if !utils.IsInDBTag(request.Field, Comment{}) {
+		request.Field = "created_at"
+		log.Println("WARNING: field is not in database! defaulted to", request.Field)
+	}
+
+	if !utils.IsInSortingOrder(request.SortBy) {
+		request.SortBy = "ASC"
+		log.Println("WARNING: sortBy is not valid! defaulted to", request.SortBy)
+	}
+
+	var total int
+	err := repository.Get(&total, "SELECT COUNT(*) FROM comment c WHERE c.post_id = ? AND c.parent_id = ? AND c.is_deleted = ?"+excludeBlockedAndMuted, postId, parentId, isDeleted, currentUserId, currentUserId, currentUserId)
+	if err != nil {
+		return nil, err
+	}
+
+	replies := make([]Comment, 0, request.PageSize)
+	query := fmt.Sprintf("%s WHERE c.post_id = ? AND c.parent_id = ? AND c.is_deleted = ? %s ORDER BY %s %s LIMIT ? OFFSET ?", selectComment, excludeBlockedAndMuted, request.Field, request.SortBy)
+	err = repository.Select(&replies, query, postId, parentId, isDeleted, currentUserId, currentUserId, currentUserId, request.PageSize, request.Offset())
+	if err != nil {
+		return nil, err
+	}
+
+	return paging.NewPage(replies, request, total), nil
+}
+
 func (repository RepositoryImpl) updateContent(currentUserId, postId, commentId int, newContent string) (affectedRows int64, err error) {
 	result, err := repository.NamedExec("UPDATE comment SET content = :content WHERE id = :commentId AND author_id = :authorId AND post_id = :postId", map[string]any{
 		"authorId":  currentUserId,
@@ -139,3 +208,58 @@ func (repository RepositoryImpl) deleteById(currentUserId, postId, commentId int
 
 	return affectedRows, nil
 }
+
+// findSummaries counts reactions of the whole page with grouped queries instead of one query per comment
+func (repository RepositoryImpl) findSummaries(currentUserId int, commentIds []int) (map[int]*Summary, error) {
+	summaries := make(map[int]*Summary, len(commentIds))
+	for _, id := range commentIds {
+		summaries[id] = &Summary{
+			Reactions: make(map[int]int),
+		}
+	}
+
+	if len(commentIds) == 0 {
+		return summaries, nil
+	}
+
+	reactionCounts := make([]struct {
+		Id      int `db:"id"`
+		EmojiId int `db:"emoji_id"`
+		Total   int `db:"total"`
+	}, 0, len(commentIds))
+	query, args, err := sqlx.In("SELECT comment_id AS id, emoji_id, COUNT(*) AS total FROM comment_reaction WHERE comment_id IN (?) GROUP BY comment_id, emoji_id", commentIds)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repository.Select(&reactionCounts, repository.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, count := range reactionCounts {
+		summaries[count.Id].Reactions[count.EmojiId] = count.Total
+		summaries[count.Id].TotalReactions += count.Total
+	}
+
+	myReactions := make([]struct {
+		Id      int `db:"id"`
+		EmojiId int `db:"emoji_id"`
+	}, 0, len(commentIds))
+	query, args, err = sqlx.In("SELECT comment_id AS id, emoji_id FROM comment_reaction WHERE reactor_id = ? AND comment_id IN (?)", currentUserId, commentIds)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repository.Select(&myReactions, repository.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, reaction := range myReactions {
+		emojiId := reaction.EmojiId
+		summaries[reaction.Id].MyReaction = &emojiId
+	}
+
+	return summaries, nil
+}
@@ -2,16 +2,26 @@ package comment
 
 import (
 	"errors"
+	"os"
+	"social-media-application/internal/block"
 	"social-media-application/internal/paging"
+	"social-media-application/internal/post"
+	"strconv"
 	"strings"
 )
 
+// defaultMaxDepth is used when COMMENT_MAX_DEPTH is not set
+const defaultMaxDepth = 3
+
 type (
 	Service interface {
 		save(authorId, postId int, content, attachment string) (id int64, err error)
+		saveReply(authorId, postId, parentId int, content, attachment string) (id int64, err error)
 
-		getById(postId, commentId int) (Comment, error)
-		getAll(postId int, isDeleted bool, request *paging.PageRequest) (*paging.Page[Comment], error)
+		GetById(currentUserId, postId, commentId int) (Comment, error)
+		getAll(currentUserId, postId int, isDeleted, includeSummary bool, request *paging.PageRequest) (*paging.Page[Comment], error)
+		getAllReplies(currentUserId, postId, parentId int, isDeleted, includeSummary bool, request *paging.PageRequest) (*paging.Page[Comment], error)
+		getSummary(currentUserId, commentId int) (*Summary, error)
 
 		updateContent(currentUserId, postId, commentId int, newContent string) (affectedRows int64, err error)
 		updateAttachment(currentUserId, postId, commentId int, newAttachment string) (affectedRows int64, err error)
@@ -20,13 +30,17 @@ type (
 	}
 
 	ServiceImpl struct {
-		repository Repository
+		repository   Repository
+		postService  post.Service
+		blockService block.Service
 	}
 )
 
-func NewService(repository Repository) Service {
+func NewService(repository Repository, postService post.Service, blockService block.Service) Service {
 	return &ServiceImpl{
-		repository: repository,
+		repository:   repository,
+		postService:  postService,
+		blockService: blockService,
 	}
 }
 
@@ -43,6 +57,11 @@ func (s ServiceImpl) save(authorId, postId int, content, attachment string) (id
 		return 0, errors.New("content is required")
 	}
 
+	_, err = s.postService.GetById(authorId, postId)
+	if err != nil {
+		return 0, err
+	}
+
 	id, err = s.repository.save(authorId, postId, content, attachment)
 	if err != nil {
 		return 0, err
@@ -51,28 +70,143 @@ func (s ServiceImpl) save(authorId, postId int, content, attachment string) (id
 	return id, nil
 }
 
-func (s ServiceImpl) getById(postId, commentId int) (Comment, error) {
+func (s ServiceImpl) saveReply(authorId, postId, parentId int, content, attachment string) (id int64, err error) {
+	if authorId <= 0 {
+		return 0, errors.New("author is required")
+	}
+
+	if postId <= 0 {
+		return 0, errors.New("post is required")
+	}
+
+	if parentId <= 0 {
+		return 0, errors.New("parent comment is required")
+	}
+
+	if strings.TrimSpace(content) == "" {
+		return 0, errors.New("content is required")
+	}
+
+	parent, err := s.GetById(authorId, postId, parentId)
+	if err != nil {
+		return 0, err
+	}
+
+	if parent.IsDeleted {
+		return 0, errors.New("cannot reply to a deleted comment")
+	}
+
+	depth := parent.Depth + 1
+	if depth > maxDepth() {
+		return 0, errors.New("maximum reply depth reached")
+	}
+
+	id, err = s.repository.saveReply(authorId, postId, parentId, depth, content, attachment)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// GetById also guards comment reactions
+// so blocked users cannot read or interact with each other's comments
+func (s ServiceImpl) GetById(currentUserId, postId, commentId int) (Comment, error) {
+	_, err := s.postService.GetById(currentUserId, postId)
+	if err != nil {
+		return Comment{}, err
+	}
+
 	comment, err := s.repository.findById(postId, commentId)
 	if err != nil {
 		return Comment{}, err
 	}
 
+	if comment.AuthorId != currentUserId {
+		isBlocked, err := s.blockService.IsBlocked(currentUserId, comment.AuthorId)
+		if err != nil {
+			return Comment{}, err
+		}
+
+		if isBlocked {
+			return Comment{}, errors.New("comment is not available")
+		}
+	}
+
 	return comment, nil
 }
 
-func (s ServiceImpl) getAll(postId int, isDeleted bool, request *paging.PageRequest) (*paging.Page[Comment], error) {
+func (s ServiceImpl) getAll(currentUserId, postId int, isDeleted, includeSummary bool, request *paging.PageRequest) (*paging.Page[Comment], error) {
 	if postId <= 0 {
 		return nil, errors.New("postId is required")
 	}
 
-	comments, err := s.repository.findAll(postId, isDeleted, request)
+	_, err := s.postService.GetById(currentUserId, postId)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, err := s.repository.findAll(currentUserId, postId, isDeleted, request)
 	if err != nil {
 		return nil, err
 	}
 
+	if includeSummary {
+		err = s.summarize(currentUserId, comments.Content)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return comments, nil
 }
 
+func (s ServiceImpl) getAllReplies(currentUserId, postId, parentId int, isDeleted, includeSummary bool, request *paging.PageRequest) (*paging.Page[Comment], error) {
+	if postId <= 0 {
+		return nil, errors.New("postId is required")
+	}
+
+	if parentId <= 0 {
+		return nil, errors.New("parentId is required")
+	}
+
+	_, err := s.GetById(currentUserId, postId, parentId)
+	if err != nil {
+		return nil, err
+	}
+
+	replies, err := s.repository.findAllReplies(currentUserId, postId, parentId, isDeleted, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if includeSummary {
+		err = s.summarize(currentUserId, replies.Content)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return replies, nil
+}
+
+func (s ServiceImpl) getSummary(currentUserId, commentId int) (*Summary, error) {
+	if currentUserId <= 0 {
+		return nil, errors.New("currentUserId is required")
+	}
+
+	if commentId <= 0 {
+		return nil, errors.New("commentId is required")
+	}
+
+	summaries, err := s.repository.findSummaries(currentUserId, []int{commentId})
+	if err != nil {
+		return nil, err
+	}
+
+	return summaries[commentId], nil
+}
+
 func (s ServiceImpl) updateContent(currentUserId, postId, commentId int, newContent string) (affectedRows int64, err error) {
 	if currentUserId <= 0 {
 		return 0, errors.New("currentUserId is required")
@@ -155,3 +289,32 @@ func (s ServiceImpl) deleteById(currentUserId, postId, commentId int) (affectedR
 
 	return affectedRows, nil
 }
+
+// summarize fills the summary of every comment in place
+func (s ServiceImpl) summarize(currentUserId int, comments []Comment) error {
+	commentIds := make([]int, 0, len(comments))
+	for _, comment := range comments {
+		commentIds = append(commentIds, comment.Id)
+	}
+
+	summaries, err := s.repository.findSummaries(currentUserId, commentIds)
+	if err != nil {
+		return err
+	}
+
+	for i := range comments {
+		comments[i].Summary = summaries[comments[i].Id]
+	}
+
+	return nil
+}
+
+// maxDepth is how deep replies can be nested, top level comments are depth 0
+func maxDepth() int {
+	depth, err := strconv.Atoi(os.Getenv("COMMENT_MAX_DEPTH"))
+	if err != nil || depth < 0 {
+		return defaultMaxDepth
+	}
+
+	return depth
+}
@@ -3,6 +3,7 @@ package emoji
 import (
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"social-media-application/internal/role"
 	middleware "social-media-application/middlewares"
 	"strconv"
 )
@@ -32,7 +33,7 @@ func NewController(service Service) Controller {
 func (c ControllerImpl) RegisterRoutes(e *gin.Engine) {
 	r := e.Group("/emojis", middleware.JWT)
 	{
-		r.POST("", c.save)
+		r.POST("", middleware.HasPermission(role.EMOJIS_WRITE), c.save)
 		r.GET("/id/:id", c.getById)
 		r.GET("/name/:name", c.getByName)
 		r.GET("", c.getAll)
@@ -0,0 +1,237 @@
+package follow
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"social-media-application/internal/paging"
+	"social-media-application/middlewares"
+	"strconv"
+)
+
+type (
+	Controller interface {
+		save(ctx *gin.Context)
+
+		getAllFollowers(ctx *gin.Context)
+		getAllFollowings(ctx *gin.Context)
+
+		getCounts(ctx *gin.Context)
+		isFollowing(ctx *gin.Context)
+
+		delete(ctx *gin.Context)
+
+		RegisterRoutes(e *gin.Engine)
+	}
+
+	ControllerImpl struct {
+		service Service
+	}
+)
+
+func NewController(service Service) Controller {
+	return &ControllerImpl{
+		service: service,
+	}
+}
+
+func (c ControllerImpl) RegisterRoutes(e *gin.Engine) {
+	r := e.Group("/users/follows", middleware.JWT)
+	{
+		r.POST("/:id", c.save)
+
+		r.GET("/:id/followers", c.getAllFollowers)
+		r.GET("/:id/followings", c.getAllFollowings)
+
+		r.GET("/:id/counts", c.getCounts)
+		r.GET("/:id/is-following", c.isFollowing)
+
+		r.DELETE("/:id", c.delete)
+	}
+}
+
+func (c ControllerImpl) save(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "save failed " + err.Error(),
+		})
+		return
+	}
+
+	followeeId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "save failed " + err.Error(),
+		})
+		return
+	}
+
+	id, err := c.service.save(sub, followeeId)
+	if err != nil {
+		if errors.Is(err, ErrAlreadyFollowing) {
+			ctx.JSON(http.StatusConflict, gin.H{
+				"message": "save failed " + err.Error(),
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "save failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, id)
+}
+
+func (c ControllerImpl) getAllFollowers(ctx *gin.Context) {
+	userId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all followers failed " + err.Error(),
+		})
+		return
+	}
+
+	page := ctx.DefaultQuery("page", "1")
+	pageSize := ctx.DefaultQuery("pageSize", "10")
+	field := ctx.DefaultQuery("field", "created_at")
+	sortBy := ctx.DefaultQuery("sortBy", "DESC")
+	request, err := paging.NewPageRequestStr(page, pageSize, field, sortBy)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all followers failed " + err.Error(),
+		})
+		return
+	}
+
+	followers, err := c.service.getAllFollowers(userId, request)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "get all followers failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, followers)
+}
+
+func (c ControllerImpl) getAllFollowings(ctx *gin.Context) {
+	userId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all followings failed " + err.Error(),
+		})
+		return
+	}
+
+	page := ctx.DefaultQuery("page", "1")
+	pageSize := ctx.DefaultQuery("pageSize", "10")
+	field := ctx.DefaultQuery("field", "created_at")
+	sortBy := ctx.DefaultQuery("sortBy", "DESC")
+	request, err := paging.NewPageRequestStr(page, pageSize, field, sortBy)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all followings failed " + err.Error(),
+		})
+		return
+	}
+
+	followings, err := c.service.getAllFollowings(userId, request)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "get all followings failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, followings)
+}
+
+func (c ControllerImpl) getCounts(ctx *gin.Context) {
+	userId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get counts failed " + err.Error(),
+		})
+		return
+	}
+
+	followers, err := c.service.countFollowers(userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "get counts failed " + err.Error(),
+		})
+		return
+	}
+
+	followings, err := c.service.countFollowings(userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "get counts failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"followers":  followers,
+		"followings": followings,
+	})
+}
+
+func (c ControllerImpl) isFollowing(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "is following failed " + err.Error(),
+		})
+		return
+	}
+
+	followeeId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "is following failed " + err.Error(),
+		})
+		return
+	}
+
+	isFollowing, err := c.service.IsFollowing(sub, followeeId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "is following failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, isFollowing)
+}
+
+func (c ControllerImpl) delete(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "delete failed " + err.Error(),
+		})
+		return
+	}
+
+	followeeId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "delete failed " + err.Error(),
+		})
+		return
+	}
+
+	_, err = c.service.delete(sub, followeeId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "delete failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,15 @@
+package follow
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrAlreadyFollowing = errors.New("already following this user")
+
+type Follow struct {
+	Id         int       `json:"id" db:"id"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	FollowerId int       `json:"follower_id" db:"follower_id"`
+	FolloweeId int       `json:"followee_id" db:"followee_id"`
+}
@@ -0,0 +1,149 @@
+package follow
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"log"
+	"social-media-application/internal/paging"
+	"social-media-application/utils"
+)
+
+type (
+	Repository interface {
+		save(followerId, followeeId int) (id int64, err error)
+
+		findAllFollowers(userId int, request *paging.PageRequest) (*paging.Page[Follow], error)
+		findAllFollowings(userId int, request *paging.PageRequest) (*paging.Page[Follow], error)
+
+		countFollowers(userId int) (total int, err error)
+		countFollowings(userId int) (total int, err error)
+
+		delete(followerId, followeeId int) (affectedRows int64, err error)
+
+		isFollowing(followerId, followeeId int) (bool, error)
+	}
+
+	RepositoryImpl struct {
+		*sqlx.DB
+	}
+)
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &RepositoryImpl{
+		DB: db,
+	}
+}
+
+func (repository RepositoryImpl) save(followerId, followeeId int) (id int64, err error) {
+	result, err := repository.NamedExec("INSERT INTO follow (follower_id, followee_id) VALUES (:followerId, :followeeId)", map[string]any{
+		"followerId": followerId,
+		"followeeId": followeeId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	id, err = result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (repository RepositoryImpl) findAllFollowers(userId int, request *paging.PageRequest) (*paging.Page[Follow], error) {
+	if !utils.IsInDBTag(request.Field, Follow{}) {
+		request.Field = "created_at"
+		log.Println("WARNING: field is not in database! defaulted to", request.Field)
+	}
+
+	if !utils.IsInSortingOrder(request.SortBy) {
+		request.SortBy = "DESC"
+		log.Println("WARNING: sortBy is not valid! defaulted to", request.SortBy)
+	}
+
+	total, err := repository.countFollowers(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	follows := make([]Follow, 0, request.PageSize)
+	query := fmt.Sprintf("SELECT * FROM follow WHERE followee_id = ? ORDER BY %s %s LIMIT ? OFFSET ?", request.Field, request.SortBy)
+	err = repository.Select(&follows, query, userId, request.PageSize, request.Offset())
+	if err != nil {
+		return nil, err
+	}
+
+	return paging.NewPage(follows, request, total), nil
+}
+
+func (repository RepositoryImpl) findAllFollowings(userId int, request *paging.PageRequest) (*paging.Page[Follow], error) {
+	if !utils.IsInDBTag(request.Field, Follow{}) {
+		request.Field = "created_at"
+		log.Println("WARNING: field is not in database! defaulted to", request.Field)
+	}
+
+	if !utils.IsInSortingOrder(request.SortBy) {
+		request.SortBy = "DESC"
+		log.Println("WARNING: sortBy is not valid! defaulted to", request.SortBy)
+	}
+
+	total, err := repository.countFollowings(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	follows := make([]Follow, 0, request.PageSize)
+	query := fmt.Sprintf("SELECT * FROM follow WHERE follower_id = ? ORDER BY %s %s LIMIT ? OFFSET ?", request.Field, request.SortBy)
+	err = repository.Select(&follows, query, userId, request.PageSize, request.Offset())
+	if err != nil {
+		return nil, err
+	}
+
+	return paging.NewPage(follows, request, total), nil
+}
+
+func (repository RepositoryImpl) countFollowers(userId int) (total int, err error) {
+	err = repository.Get(&total, "SELECT COUNT(*) FROM follow WHERE followee_id = ?", userId)
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (repository RepositoryImpl) countFollowings(userId int) (total int, err error) {
+	err = repository.Get(&total, "SELECT COUNT(*) FROM follow WHERE follower_id = ?", userId)
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (repository RepositoryImpl) delete(followerId, followeeId int) (affectedRows int64, err error) {
+	result, err := repository.NamedExec("DELETE FROM follow WHERE follower_id = :followerId AND followee_id = :followeeId", map[string]any{
+		"followerId": followerId,
+		"followeeId": followeeId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
+func (repository RepositoryImpl) isFollowing(followerId, followeeId int) (bool, error) {
+	var exists bool
+	err := repository.Get(&exists, "SELECT EXISTS(SELECT 1 FROM follow WHERE follower_id = ? AND followee_id = ?)", followerId, followeeId)
+	if err != nil {
+		return exists, err
+	}
+
+	return exists, nil
+}
@@ -0,0 +1,170 @@
+package follow
+
+import (
+	"errors"
+	"social-media-application/internal/block"
+	"social-media-application/internal/paging"
+	"social-media-application/utils"
+)
+
+type (
+	Service interface {
+		save(followerId, followeeId int) (id int64, err error)
+
+		getAllFollowers(userId int, request *paging.PageRequest) (*paging.Page[Follow], error)
+		getAllFollowings(userId int, request *paging.PageRequest) (*paging.Page[Follow], error)
+
+		countFollowers(userId int) (total int, err error)
+		countFollowings(userId int) (total int, err error)
+
+		delete(followerId, followeeId int) (affectedRows int64, err error)
+
+		IsFollowing(followerId, followeeId int) (bool, error)
+	}
+
+	ServiceImpl struct {
+		repository   Repository
+		blockService block.Service
+	}
+)
+
+func NewService(repository Repository, blockService block.Service) Service {
+	return &ServiceImpl{
+		repository:   repository,
+		blockService: blockService,
+	}
+}
+
+func (s ServiceImpl) save(followerId, followeeId int) (id int64, err error) {
+	if followerId <= 0 {
+		return 0, errors.New("follower id is required")
+	}
+
+	if followeeId <= 0 {
+		return 0, errors.New("followee id is required")
+	}
+
+	if followerId == followeeId {
+		return 0, errors.New("cannot follow yourself")
+	}
+
+	isBlocked, err := s.blockService.IsBlocked(followerId, followeeId)
+	if err != nil {
+		return 0, err
+	}
+
+	if isBlocked {
+		return 0, errors.New("cannot follow this user")
+	}
+
+	isFollowing, err := s.repository.isFollowing(followerId, followeeId)
+	if err != nil {
+		return 0, err
+	}
+
+	if isFollowing {
+		return 0, ErrAlreadyFollowing
+	}
+
+	// Concurrent follows can both pass the check above, the unique key rejects the later one
+	id, err = s.repository.save(followerId, followeeId)
+	if err != nil {
+		if utils.IsDuplicateEntry(err) {
+			return 0, ErrAlreadyFollowing
+		}
+
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (s ServiceImpl) getAllFollowers(userId int, request *paging.PageRequest) (*paging.Page[Follow], error) {
+	if userId <= 0 {
+		return nil, errors.New("user id is required")
+	}
+
+	followers, err := s.repository.findAllFollowers(userId, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return followers, nil
+}
+
+func (s ServiceImpl) getAllFollowings(userId int, request *paging.PageRequest) (*paging.Page[Follow], error) {
+	if userId <= 0 {
+		return nil, errors.New("user id is required")
+	}
+
+	followings, err := s.repository.findAllFollowings(userId, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return followings, nil
+}
+
+func (s ServiceImpl) countFollowers(userId int) (total int, err error) {
+	if userId <= 0 {
+		return 0, errors.New("user id is required")
+	}
+
+	total, err = s.repository.countFollowers(userId)
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (s ServiceImpl) countFollowings(userId int) (total int, err error) {
+	if userId <= 0 {
+		return 0, errors.New("user id is required")
+	}
+
+	total, err = s.repository.countFollowings(userId)
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (s ServiceImpl) delete(followerId, followeeId int) (affectedRows int64, err error) {
+	if followerId <= 0 {
+		return 0, errors.New("follower id is required")
+	}
+
+	if followeeId <= 0 {
+		return 0, errors.New("followee id is required")
+	}
+
+	affectedRows, err = s.repository.delete(followerId, followeeId)
+	if err != nil {
+		return 0, err
+	}
+
+	if affectedRows <= 0 {
+		return 0, errors.New("current user is not following this user")
+	}
+
+	return affectedRows, nil
+}
+
+func (s ServiceImpl) IsFollowing(followerId, followeeId int) (bool, error) {
+	if followerId <= 0 {
+		return false, errors.New("follower id is required")
+	}
+
+	if followeeId <= 0 {
+		return false, errors.New("followee id is required")
+	}
+
+	isFollowing, err := s.repository.isFollowing(followerId, followeeId)
+	if err != nil {
+		return false, err
+	}
+
+	return isFollowing, nil
+}
@@ -0,0 +1,330 @@
+package friendship
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"social-media-application/internal/paging"
+	"social-media-application/middlewares"
+	"strconv"
+)
+
+type (
+	Controller interface {
+		send(ctx *gin.Context)
+
+		getAllFriends(ctx *gin.Context)
+		getAllIncoming(ctx *gin.Context)
+		getAllOutgoing(ctx *gin.Context)
+
+		countMutualFriends(ctx *gin.Context)
+
+		accept(ctx *gin.Context)
+		decline(ctx *gin.Context)
+
+		cancel(ctx *gin.Context)
+		unfriend(ctx *gin.Context)
+
+		RegisterRoutes(e *gin.Engine)
+	}
+
+	ControllerImpl struct {
+		service Service
+	}
+)
+
+func NewController(service Service) Controller {
+	return &ControllerImpl{
+		service: service,
+	}
+}
+
+func (c ControllerImpl) RegisterRoutes(e *gin.Engine) {
+	r := e.Group("/users/friends", middleware.JWT)
+	{
+		r.GET("", c.getAllFriends)
+		r.GET("/:id/mutual-count", c.countMutualFriends)
+		r.DELETE("/:id", c.unfriend)
+
+		r.POST("/requests/:userId", c.send)
+
+		r.GET("/requests/incoming", c.getAllIncoming)
+		r.GET("/requests/outgoing", c.getAllOutgoing)
+
+		r.PATCH("/requests/:requestId/accept", c.accept)
+		r.PATCH("/requests/:requestId/decline", c.decline)
+
+		r.DELETE("/requests/:requestId", c.cancel)
+	}
+}
+
+func (c ControllerImpl) send(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "send failed " + err.Error(),
+		})
+		return
+	}
+
+	addresseeId, err := strconv.Atoi(ctx.Param("userId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "send failed " + err.Error(),
+		})
+		return
+	}
+
+	id, err := c.service.send(sub, addresseeId)
+	if err != nil {
+		if errors.Is(err, ErrAlreadyFriends) || errors.Is(err, ErrFriendRequestExists) || errors.Is(err, ErrFriendRequestDeclined) {
+			ctx.JSON(http.StatusConflict, gin.H{
+				"message": "send failed " + err.Error(),
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "send failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, id)
+}
+
+func (c ControllerImpl) getAllFriends(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all friends failed " + err.Error(),
+		})
+		return
+	}
+
+	page := ctx.DefaultQuery("page", "1")
+	pageSize := ctx.DefaultQuery("pageSize", "10")
+	field := ctx.DefaultQuery("field", "created_at")
+	sortBy := ctx.DefaultQuery("sortBy", "DESC")
+	request, err := paging.NewPageRequestStr(page, pageSize, field, sortBy)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all friends failed " + err.Error(),
+		})
+		return
+	}
+
+	friends, err := c.service.getAllFriends(sub, request)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "get all friends failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, friends)
+}
+
+func (c ControllerImpl) getAllIncoming(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all incoming failed " + err.Error(),
+		})
+		return
+	}
+
+	page := ctx.DefaultQuery("page", "1")
+	pageSize := ctx.DefaultQuery("pageSize", "10")
+	field := ctx.DefaultQuery("field", "created_at")
+	sortBy := ctx.DefaultQuery("sortBy", "DESC")
+	request, err := paging.NewPageRequestStr(page, pageSize, field, sortBy)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all incoming failed " + err.Error(),
+		})
+		return
+	}
+
+	incoming, err := c.service.getAllIncoming(sub, request)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "get all incoming failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, incoming)
+}
+
+func (c ControllerImpl) getAllOutgoing(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all outgoing failed " + err.Error(),
+		})
+		return
+	}
+
+	page := ctx.DefaultQuery("page", "1")
+	pageSize := ctx.DefaultQuery("pageSize", "10")
+	field := ctx.DefaultQuery("field", "created_at")
+	sortBy := ctx.DefaultQuery("sortBy", "DESC")
+	request, err := paging.NewPageRequestStr(page, pageSize, field, sortBy)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all outgoing failed " + err.Error(),
+		})
+		return
+	}
+
+	outgoing, err := c.service.getAllOutgoing(sub, request)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "get all outgoing failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, outgoing)
+}
+
+func (c ControllerImpl) countMutualFriends(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "count mutual friends failed " + err.Error(),
+		})
+		return
+	}
+
+	otherUserId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "count mutual friends failed " + err.Error(),
+		})
+		return
+	}
+
+	total, err := c.service.countMutualFriends(sub, otherUserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "count mutual friends failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, total)
+}
+
+func (c ControllerImpl) accept(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "accept failed " + err.Error(),
+		})
+		return
+	}
+
+	friendshipId, err := strconv.Atoi(ctx.Param("requestId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "accept failed " + err.Error(),
+		})
+		return
+	}
+
+	_, err = c.service.accept(sub, friendshipId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "accept failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, friendshipId)
+}
+
+func (c ControllerImpl) decline(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "decline failed " + err.Error(),
+		})
+		return
+	}
+
+	friendshipId, err := strconv.Atoi(ctx.Param("requestId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "decline failed " + err.Error(),
+		})
+		return
+	}
+
+	_, err = c.service.decline(sub, friendshipId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "decline failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, friendshipId)
+}
+
+func (c ControllerImpl) cancel(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "cancel failed " + err.Error(),
+		})
+		return
+	}
+
+	friendshipId, err := strconv.Atoi(ctx.Param("requestId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "cancel failed " + err.Error(),
+		})
+		return
+	}
+
+	_, err = c.service.cancel(sub, friendshipId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "cancel failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+func (c ControllerImpl) unfriend(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "unfriend failed " + err.Error(),
+		})
+		return
+	}
+
+	otherUserId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "unfriend failed " + err.Error(),
+		})
+		return
+	}
+
+	_, err = c.service.unfriend(sub, otherUserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "unfriend failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
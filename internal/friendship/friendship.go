@@ -0,0 +1,40 @@
+package friendship
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrAlreadyFriends        = errors.New("already friends with this user")
+	ErrFriendRequestExists   = errors.New("friend request already exists")
+	ErrFriendRequestDeclined = errors.New("friend request was declined, try again later")
+)
+
+// declinedCooldown is how long the requester has to wait before sending a declined request again
+// the user who declined it can send one anytime
+const declinedCooldown = 30 * 24 * time.Hour
+
+const (
+	PENDING  = "PENDING"
+	ACCEPTED = "ACCEPTED"
+	DECLINED = "DECLINED"
+)
+
+type Friendship struct {
+	Id          int       `json:"id" db:"id"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	Status      string    `json:"status" db:"status"`
+	RequesterId int       `json:"requester_id" db:"requester_id"`
+	AddresseeId int       `json:"addressee_id" db:"addressee_id"`
+
+	// UserLowId and UserHighId are generated so the pair is unique in either direction
+	UserLowId  int `json:"-" db:"user_low_id"`
+	UserHighId int `json:"-" db:"user_high_id"`
+}
+
+// CanResend the updated_at of a declined request is when it was declined
+func (f Friendship) CanResend(requesterId int) bool {
+	return f.RequesterId != requesterId || time.Since(f.UpdatedAt) >= declinedCooldown
+}
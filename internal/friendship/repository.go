@@ -0,0 +1,286 @@
+package friendship
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"log"
+	"social-media-application/internal/paging"
+	"social-media-application/utils"
+)
+
+type (
+	Repository interface {
+		save(requesterId, addresseeId int) (id int64, err error)
+		resend(friendshipId, requesterId, addresseeId int) (affectedRows int64, err error)
+
+		findBetween(userId, otherUserId int) (Friendship, error)
+
+		findAllFriends(userId int, request *paging.PageRequest) (*paging.Page[Friendship], error)
+		findAllIncoming(userId int, request *paging.PageRequest) (*paging.Page[Friendship], error)
+		findAllOutgoing(userId int, request *paging.PageRequest) (*paging.Page[Friendship], error)
+
+		countMutualFriends(userId, otherUserId int) (total int, err error)
+
+		respond(addresseeId, friendshipId int, status string) (affectedRows int64, err error)
+
+		cancel(requesterId, friendshipId int) (affectedRows int64, err error)
+		unfriend(userId, otherUserId int) (affectedRows int64, err error)
+
+		isFriend(userId, otherUserId int) (bool, error)
+	}
+
+	RepositoryImpl struct {
+		*sqlx.DB
+	}
+)
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &RepositoryImpl{
+		DB: db,
+	}
+}
+
+func (repository RepositoryImpl) save(requesterId, addresseeId int) (id int64, err error) {
+	result, err := repository.NamedExec("INSERT INTO friendship (requester_id, addressee_id) VALUES (:requesterId, :addresseeId)", map[string]any{
+		"requesterId": requesterId,
+		"addresseeId": addresseeId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	id, err = result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (repository RepositoryImpl) resend(friendshipId, requesterId, addresseeId int) (affectedRows int64, err error) {
+	query := `
+		UPDATE friendship
+		SET status = 'PENDING',
+		created_at = NOW(),
+		requester_id = :requesterId,
+		addressee_id = :addresseeId
+		WHERE id = :friendshipId
+		AND status = 'DECLINED'
+	`
+	result, err := repository.NamedExec(query, map[string]any{
+		"friendshipId": friendshipId,
+		"requesterId":  requesterId,
+		"addresseeId":  addresseeId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
+func (repository RepositoryImpl) findBetween(userId, otherUserId int) (Friendship, error) {
+	var friendship Friendship
+	query := `
+		SELECT *
+		FROM friendship
+		WHERE (requester_id = ? AND addressee_id = ?)
+		OR (requester_id = ? AND addressee_id = ?)
+	`
+	err := repository.Get(&friendship, query, userId, otherUserId, otherUserId, userId)
+	if err != nil {
+		return Friendship{}, err
+	}
+
+	return friendship, nil
+}
+
+func (repository RepositoryImpl) findAllFriends(userId int, request *paging.PageRequest) (*paging.Page[Friendship], error) {
+	if !utils.IsInDBTag(request.Field, Friendship{}) {
+		request.Field = "created_at"
+		log.Println("WARNING: field is not in database! defaulted to", request.Field)
+	}
+
+	if !utils.IsInSortingOrder(request.SortBy) {
+		request.SortBy = "DESC"
+		log.Println("WARNING: sortBy is not valid! defaulted to", request.SortBy)
+	}
+
+	var total int
+	err := repository.Get(&total, "SELECT COUNT(*) FROM friendship WHERE status = 'ACCEPTED' AND (requester_id = ? OR addressee_id = ?)", userId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	friendships := make([]Friendship, 0, request.PageSize)
+	query := fmt.Sprintf("SELECT * FROM friendship WHERE status = 'ACCEPTED' AND (requester_id = ? OR addressee_id = ?) ORDER BY %s %s LIMIT ? OFFSET ?", request.Field, request.SortBy)
+	err = repository.Select(&friendships, query, userId, userId, request.PageSize, request.Offset())
+	if err != nil {
+		return nil, err
+	}
+
+	return paging.NewPage(friendships, request, total), nil
+}
+
+func (repository RepositoryImpl) findAllIncoming(userId int, request *paging.PageRequest) (*paging.Page[Friendship], error) {
+	if !utils.IsInDBTag(request.Field, Friendship{}) {
+		request.Field = "created_at"
+		log.Println("WARNING: field is not in database! defaulted to", request.Field)
+	}
+
+	if !utils.IsInSortingOrder(request.SortBy) {
+		request.SortBy = "DESC"
+		log.Println("WARNING: sortBy is not valid! defaulted to", request.SortBy)
+	}
+
+	var total int
+	err := repository.Get(&total, "SELECT COUNT(*) FROM friendship WHERE status = 'PENDING' AND addressee_id = ?", userId)
+	if err != nil {
+		return nil, err
+	}
+
+	friendships := make([]Friendship, 0, request.PageSize)
+	query := fmt.Sprintf("SELECT * FROM friendship WHERE status = 'PENDING' AND addressee_id = ? ORDER BY %s %s LIMIT ? OFFSET ?", request.Field, request.SortBy)
+	err = repository.Select(&friendships, query, userId, request.PageSize, request.Offset())
+	if err != nil {
+		return nil, err
+	}
+
+	return paging.NewPage(friendships, request, total), nil
+}
+
+func (repository RepositoryImpl) findAllOutgoing(userId int, request *paging.PageRequest) (*paging.Page[Friendship], error) {
+	if !utils.IsInDBTag(request.Field, Friendship{}) {
+		request.Field = "created_at"
+		log.Println("WARNING: field is not in database! defaulted to", request.Field)
+	}
+
+	if !utils.IsInSortingOrder(request.SortBy) {
+		request.SortBy = "DESC"
+		log.Println("WARNING: sortBy is not valid! defaulted to", request.SortBy)
+	}
+
+	var total int
+	err := repository.Get(&total, "SELECT COUNT(*) FROM friendship WHERE status = 'PENDING' AND requester_id = ?", userId)
+	if err != nil {
+		return nil, err
+	}
+
+	friendships := make([]Friendship, 0, request.PageSize)
+	query := fmt.Sprintf("SELECT * FROM friendship WHERE status = 'PENDING' AND requester_id = ? ORDER BY %s %s LIMIT ? OFFSET ?", request.Field, request.SortBy)
+	err = repository.Select(&friendships, query, userId, request.PageSize, request.Offset())
+	if err != nil {
+		return nil, err
+	}
+
+	return paging.NewPage(friendships, request, total), nil
+}
+
+func (repository RepositoryImpl) countMutualFriends(userId, otherUserId int) (total int, err error) {
+	query := `
+		SELECT COUNT(*)
+		FROM (
+			SELECT IF(requester_id = ?, addressee_id, requester_id) AS friend_id
+			FROM friendship
+			WHERE status = 'ACCEPTED'
+			AND (requester_id = ? OR addressee_id = ?)
+		) a
+		JOIN (
+			SELECT IF(requester_id = ?, addressee_id, requester_id) AS friend_id
+			FROM friendship
+			WHERE status = 'ACCEPTED'
+			AND (requester_id = ? OR addressee_id = ?)
+		) b ON a.friend_id = b.friend_id
+	`
+	err = repository.Get(&total, query, userId, userId, userId, otherUserId, otherUserId, otherUserId)
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (repository RepositoryImpl) respond(addresseeId, friendshipId int, status string) (affectedRows int64, err error) {
+	result, err := repository.NamedExec("UPDATE friendship SET status = :status WHERE id = :friendshipId AND addressee_id = :addresseeId AND status = 'PENDING'", map[string]any{
+		"status":       status,
+		"friendshipId": friendshipId,
+		"addresseeId":  addresseeId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
+func (repository RepositoryImpl) cancel(requesterId, friendshipId int) (affectedRows int64, err error) {
+	result, err := repository.NamedExec("DELETE FROM friendship WHERE id = :friendshipId AND requester_id = :requesterId AND status = 'PENDING'", map[string]any{
+		"friendshipId": friendshipId,
+		"requesterId":  requesterId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
+func (repository RepositoryImpl) unfriend(userId, otherUserId int) (affectedRows int64, err error) {
+	query := `
+		DELETE FROM friendship
+		WHERE status = 'ACCEPTED'
+		AND (
+			(requester_id = :userId AND addressee_id = :otherUserId)
+			OR (requester_id = :otherUserId AND addressee_id = :userId)
+		)
+	`
+	result, err := repository.NamedExec(query, map[string]any{
+		"userId":      userId,
+		"otherUserId": otherUserId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
+func (repository RepositoryImpl) isFriend(userId, otherUserId int) (bool, error) {
+	var exists bool
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM friendship
+			WHERE status = 'ACCEPTED'
+			AND (
+				(requester_id = ? AND addressee_id = ?)
+				OR (requester_id = ? AND addressee_id = ?)
+			)
+		)
+	`
+	err := repository.Get(&exists, query, userId, otherUserId, otherUserId, userId)
+	if err != nil {
+		return exists, err
+	}
+
+	return exists, nil
+}
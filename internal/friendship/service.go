@@ -0,0 +1,275 @@
+package friendship
+
+import (
+	"database/sql"
+	"errors"
+	"social-media-application/internal/block"
+	"social-media-application/internal/paging"
+	"social-media-application/internal/user"
+	"social-media-application/utils"
+)
+
+type (
+	Service interface {
+		send(requesterId, addresseeId int) (id int64, err error)
+
+		getAllFriends(userId int, request *paging.PageRequest) (*paging.Page[Friendship], error)
+		getAllIncoming(userId int, request *paging.PageRequest) (*paging.Page[Friendship], error)
+		getAllOutgoing(userId int, request *paging.PageRequest) (*paging.Page[Friendship], error)
+
+		countMutualFriends(userId, otherUserId int) (total int, err error)
+
+		accept(addresseeId, friendshipId int) (affectedRows int64, err error)
+		decline(addresseeId, friendshipId int) (affectedRows int64, err error)
+
+		cancel(requesterId, friendshipId int) (affectedRows int64, err error)
+		unfriend(userId, otherUserId int) (affectedRows int64, err error)
+
+		IsFriend(userId, otherUserId int) (bool, error)
+	}
+
+	ServiceImpl struct {
+		repository   Repository
+		userService  user.Service
+		blockService block.Service
+	}
+)
+
+func NewService(repository Repository, userService user.Service, blockService block.Service) Service {
+	return &ServiceImpl{
+		repository:   repository,
+		userService:  userService,
+		blockService: blockService,
+	}
+}
+
+func (s ServiceImpl) send(requesterId, addresseeId int) (id int64, err error) {
+	if requesterId <= 0 {
+		return 0, errors.New("requester id is required")
+	}
+
+	if addresseeId <= 0 {
+		return 0, errors.New("addressee id is required")
+	}
+
+	if requesterId == addresseeId {
+		return 0, errors.New("cannot send friend request to yourself")
+	}
+
+	addressee, err := s.userService.GetById(addresseeId)
+	if err != nil {
+		return 0, err
+	}
+
+	if !addressee.IsActive {
+		return 0, errors.New("user is deactivated")
+	}
+
+	isBlocked, err := s.blockService.IsBlocked(requesterId, addresseeId)
+	if err != nil {
+		return 0, err
+	}
+
+	if isBlocked {
+		return 0, errors.New("cannot send friend request to this user")
+	}
+
+	existing, err := s.repository.findBetween(requesterId, addresseeId)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return 0, err
+		}
+
+		// Concurrent requests between the same users in either direction can both pass the check above, the unique key rejects the later one
+		id, err = s.repository.save(requesterId, addresseeId)
+		if err != nil {
+			if utils.IsDuplicateEntry(err) {
+				return 0, ErrFriendRequestExists
+			}
+
+			return 0, err
+		}
+
+		return id, nil
+	}
+
+	switch existing.Status {
+	case ACCEPTED:
+		return 0, ErrAlreadyFriends
+	case PENDING:
+		return 0, ErrFriendRequestExists
+	}
+
+	// Declined request can be sent again by either side, the requester only after the cooldown so it cannot be used to spam
+	if !existing.CanResend(requesterId) {
+		return 0, ErrFriendRequestDeclined
+	}
+
+	affectedRows, err := s.repository.resend(existing.Id, requesterId, addresseeId)
+	if err != nil {
+		return 0, err
+	}
+
+	if affectedRows <= 0 {
+		return 0, errors.New("no affected rows")
+	}
+
+	return int64(existing.Id), nil
+}
+
+func (s ServiceImpl) getAllFriends(userId int, request *paging.PageRequest) (*paging.Page[Friendship], error) {
+	if userId <= 0 {
+		return nil, errors.New("user id is required")
+	}
+
+	friends, err := s.repository.findAllFriends(userId, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return friends, nil
+}
+
+func (s ServiceImpl) getAllIncoming(userId int, request *paging.PageRequest) (*paging.Page[Friendship], error) {
+	if userId <= 0 {
+		return nil, errors.New("user id is required")
+	}
+
+	incoming, err := s.repository.findAllIncoming(userId, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return incoming, nil
+}
+
+func (s ServiceImpl) getAllOutgoing(userId int, request *paging.PageRequest) (*paging.Page[Friendship], error) {
+	if userId <= 0 {
+		return nil, errors.New("user id is required")
+	}
+
+	outgoing, err := s.repository.findAllOutgoing(userId, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return outgoing, nil
+}
+
+func (s ServiceImpl) countMutualFriends(userId, otherUserId int) (total int, err error) {
+	if userId <= 0 {
+		return 0, errors.New("user id is required")
+	}
+
+	if otherUserId <= 0 {
+		return 0, errors.New("other user id is required")
+	}
+
+	total, err = s.repository.countMutualFriends(userId, otherUserId)
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (s ServiceImpl) accept(addresseeId, friendshipId int) (affectedRows int64, err error) {
+	if addresseeId <= 0 {
+		return 0, errors.New("addressee id is required")
+	}
+
+	if friendshipId <= 0 {
+		return 0, errors.New("friendship id is required")
+	}
+
+	affectedRows, err = s.repository.respond(addresseeId, friendshipId, ACCEPTED)
+	if err != nil {
+		return 0, err
+	}
+
+	if affectedRows <= 0 {
+		return 0, errors.New("current user has no pending friend request with this id")
+	}
+
+	return affectedRows, nil
+}
+
+func (s ServiceImpl) decline(addresseeId, friendshipId int) (affectedRows int64, err error) {
+	if addresseeId <= 0 {
+		return 0, errors.New("addressee id is required")
+	}
+
+	if friendshipId <= 0 {
+		return 0, errors.New("friendship id is required")
+	}
+
+	affectedRows, err = s.repository.respond(addresseeId, friendshipId, DECLINED)
+	if err != nil {
+		return 0, err
+	}
+
+	if affectedRows <= 0 {
+		return 0, errors.New("current user has no pending friend request with this id")
+	}
+
+	return affectedRows, nil
+}
+
+func (s ServiceImpl) cancel(requesterId, friendshipId int) (affectedRows int64, err error) {
+	if requesterId <= 0 {
+		return 0, errors.New("requester id is required")
+	}
+
+	if friendshipId <= 0 {
+		return 0, errors.New("friendship id is required")
+	}
+
+	affectedRows, err = s.repository.cancel(requesterId, friendshipId)
+	if err != nil {
+		return 0, err
+	}
+
+	if affectedRows <= 0 {
+		return 0, errors.New("current user has no pending friend request with this id")
+	}
+
+	return affectedRows, nil
+}
+
+func (s ServiceImpl) unfriend(userId, otherUserId int) (affectedRows int64, err error) {
+	if userId <= 0 {
+		return 0, errors.New("user id is required")
+	}
+
+	if otherUserId <= 0 {
+		return 0, errors.New("other user id is required")
+	}
+
+	affectedRows, err = s.repository.unfriend(userId, otherUserId)
+	if err != nil {
+		return 0, err
+	}
+
+	if affectedRows <= 0 {
+		return 0, errors.New("current user is not friends with this user")
+	}
+
+	return affectedRows, nil
+}
+
+func (s ServiceImpl) IsFriend(userId, otherUserId int) (bool, error) {
+	if userId <= 0 {
+		return false, errors.New("user id is required")
+	}
+
+	if otherUserId <= 0 {
+		return false, errors.New("other user id is required")
+	}
+
+	isFriend, err := s.repository.isFriend(userId, otherUserId)
+	if err != nil {
+		return false, err
+	}
+
+	return isFriend, nil
+}
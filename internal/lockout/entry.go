@@ -0,0 +1,62 @@
+package lockout
+
+import "time"
+
+// entry is changed the same way by every store so they make the same decisions
+type entry struct {
+	attempt   Attempt
+	expiresAt time.Time
+}
+
+// reserve returns the wait without changing the entry when the attempt is not allowed
+// a new entry expires after window even if the attempt never fails
+func (e *entry) reserve(now time.Time, window time.Duration, waitOf func(attempt Attempt, now time.Time) time.Duration) time.Duration {
+	wait := waitOf(e.attempt, now)
+	if wait > 0 {
+		return wait
+	}
+
+	if e.expiresAt.IsZero() {
+		e.expiresAt = now.Add(window)
+	}
+	e.attempt.InFlight++
+
+	return 0
+}
+
+// release is false when there was nothing in flight to take back
+func (e *entry) release() bool {
+	if e.attempt.InFlight <= 0 {
+		return false
+	}
+
+	e.attempt.InFlight--
+	return true
+}
+
+func (e *entry) fail(now time.Time, window time.Duration) {
+	if e.attempt.InFlight > 0 {
+		e.attempt.InFlight--
+	}
+
+	e.attempt.Failures++
+	e.attempt.LastFailureAt = now
+	e.expiresAt = later(now.Add(window), e.attempt.LockedUntil)
+}
+
+func (e *entry) lock(until time.Time) {
+	e.attempt.LockedUntil = until
+	e.expiresAt = later(e.expiresAt, until)
+}
+
+func (e *entry) isEmpty() bool {
+	return e.attempt == Attempt{}
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+
+	return b
+}
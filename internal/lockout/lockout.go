@@ -0,0 +1,214 @@
+package lockout
+
+import (
+	"errors"
+	"expvar"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMaxFailedAttempts      = 5
+	defaultMaxFailedAttemptsPerIp = 50
+	defaultLockoutInMinute        = 15
+
+	// Every failure doubles the wait before the next attempt, 1s, 2s, 4s, and so on
+	baseDelay = time.Second
+	maxDelay  = 30 * time.Second
+)
+
+var ErrTooManyAttempts = errors.New("too many failed attempts, try again later")
+
+// metrics are published in /debug/vars
+var metrics = expvar.NewMap("login")
+
+type (
+	Attempt struct {
+		Failures      int
+		LastFailureAt time.Time
+		LockedUntil   time.Time
+
+		// InFlight are the attempts reserved by Check that did not Fail or Succeed yet
+		InFlight int
+	}
+
+	// Store keeps the failed attempts, the in memory store only works for a single instance
+	// so multi instance deployments should use the redis store
+	Store interface {
+		// Reserve counts the attempt as in flight before the password is checked unless waitOf returns a wait
+		// the wait check and the increment have to be atomic so parallel requests cannot all pass before one fails
+		// unknown or expired keys start over with an empty attempt that expires after window
+		Reserve(key string, window time.Duration, waitOf func(attempt Attempt, now time.Time) time.Duration) (Attempt, time.Duration, error)
+		// Release takes back a reserved attempt once it succeeded, the failures and their expiry are kept as they were
+		Release(key string) error
+		// Fail turns a reserved attempt into a failure, only failures start the backoff and keep the key for another window
+		Fail(key string, window time.Duration) (Attempt, error)
+		Lock(key string, until time.Time) error
+		Reset(key string) error
+	}
+
+	Policy struct {
+		MaxFailedAttempts      int
+		MaxFailedAttemptsPerIp int
+		LockoutDuration        time.Duration
+	}
+
+	// Guard is checked before the password so a locked account does not cost a bcrypt comparison
+	Guard struct {
+		store  Store
+		policy Policy
+	}
+)
+
+// NewStore returns the store selected by LOGIN_LOCKOUT_DRIVER, either memory (default) or redis
+func NewStore(driver string) Store {
+	if driver == "redis" {
+		return NewRedisStore(os.Getenv("REDIS_ADDR"), os.Getenv("REDIS_PASSWORD"))
+	}
+
+	return NewMemoryStore()
+}
+
+func NewGuard(store Store, policy Policy) *Guard {
+	return &Guard{
+		store:  store,
+		policy: policy,
+	}
+}
+
+// PolicyFromEnv reads LOGIN_MAX_FAILED_ATTEMPTS, LOGIN_MAX_FAILED_ATTEMPTS_PER_IP, and LOGIN_LOCKOUT_IN_MINUTE
+func PolicyFromEnv() Policy {
+	return Policy{
+		MaxFailedAttempts:      intFromEnv("LOGIN_MAX_FAILED_ATTEMPTS", defaultMaxFailedAttempts),
+		MaxFailedAttemptsPerIp: intFromEnv("LOGIN_MAX_FAILED_ATTEMPTS_PER_IP", defaultMaxFailedAttemptsPerIp),
+		LockoutDuration:        time.Duration(intFromEnv("LOGIN_LOCKOUT_IN_MINUTE", defaultLockoutInMinute)) * time.Minute,
+	}
+}
+
+// Check returns ErrTooManyAttempts and how long to wait when the account or ip is locked or still backing off
+// otherwise the attempt is reserved until it fails or succeeds, so it has to be followed by Fail or Succeed
+func (g Guard) Check(email, ip string) (retryAfter time.Duration, err error) {
+	account := accountKey(email)
+	_, retryAfter, err = g.store.Reserve(account, g.policy.LockoutDuration, waitOf(g.policy.MaxFailedAttempts))
+	if err != nil {
+		return 0, err
+	}
+
+	if retryAfter > 0 {
+		metrics.Add("throttled", 1)
+		return retryAfter, ErrTooManyAttempts
+	}
+
+	_, retryAfter, err = g.store.Reserve(ipKey(ip), g.policy.LockoutDuration, waitOf(g.policy.MaxFailedAttemptsPerIp))
+	if err != nil || retryAfter > 0 {
+		g.release(account)
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	if retryAfter > 0 {
+		metrics.Add("throttled", 1)
+		return retryAfter, ErrTooManyAttempts
+	}
+
+	return 0, nil
+}
+
+// Fail counts the attempt reserved by Check as a failure and locks the account or ip once it reaches the maximum failed attempts
+func (g Guard) Fail(email, ip string) {
+	metrics.Add("failed", 1)
+
+	g.fail(accountKey(email), g.policy.MaxFailedAttempts)
+	g.fail(ipKey(ip), g.policy.MaxFailedAttemptsPerIp)
+}
+
+// Succeed resets the account and takes back the attempt reserved for the ip
+// the ip keeps its earlier failures so one valid account cannot be used to keep guessing others
+func (g Guard) Succeed(email, ip string) {
+	g.Unlock(email)
+	g.release(ipKey(ip))
+}
+
+// Unlock is also used by password reset
+func (g Guard) Unlock(email string) {
+	err := g.store.Reset(accountKey(email))
+	if err != nil {
+		log.Println("WARNING: failed to reset failed login attempts", err)
+	}
+}
+
+func (g Guard) release(key string) {
+	err := g.store.Release(key)
+	if err != nil {
+		log.Println("WARNING: failed to release login attempt", err)
+	}
+}
+
+func (g Guard) fail(key string, maxFailedAttempts int) {
+	attempt, err := g.store.Fail(key, g.policy.LockoutDuration)
+	if err != nil {
+		log.Println("WARNING: failed to count failed login attempt", err)
+		return
+	}
+
+	if attempt.Failures < maxFailedAttempts {
+		return
+	}
+
+	err = g.store.Lock(key, time.Now().Add(g.policy.LockoutDuration))
+	if err != nil {
+		log.Println("WARNING: failed to lock", key, err)
+		return
+	}
+
+	metrics.Add("locked", 1)
+	log.Printf("security event: %s locked for %s after %d failed login attempts", key, g.policy.LockoutDuration, attempt.Failures)
+}
+
+// waitOf is how long the attempt has to wait because the key is locked or still backing off
+// attempts in flight could all fail so they count toward the maximum until they finish, but they do not start the backoff
+func waitOf(maxFailedAttempts int) func(attempt Attempt, now time.Time) time.Duration {
+	return func(attempt Attempt, now time.Time) time.Duration {
+		wait := attempt.LockedUntil.Sub(now)
+		if attempt.Failures > 0 {
+			wait = max(wait, attempt.LastFailureAt.Add(delay(attempt.Failures)).Sub(now))
+		}
+
+		if wait <= 0 && attempt.Failures+attempt.InFlight >= maxFailedAttempts {
+			wait = baseDelay
+		}
+
+		return max(wait, 0)
+	}
+}
+
+func delay(failures int) time.Duration {
+	d := baseDelay
+	for i := 1; i < failures && d < maxDelay; i++ {
+		d *= 2
+	}
+
+	return min(d, maxDelay)
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+func intFromEnv(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+
+	return value
+}
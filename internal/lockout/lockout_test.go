@@ -0,0 +1,181 @@
+package lockout
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+var testPolicy = Policy{MaxFailedAttempts: 5, MaxFailedAttemptsPerIp: 50, LockoutDuration: time.Minute}
+
+const testIp = "203.0.113.7"
+
+func newTestGuard() (*Guard, *MemoryStore) {
+	store := NewMemoryStore()
+	return NewGuard(store, testPolicy), store
+}
+
+func entryOf(store *MemoryStore, key string) entry {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.entries[key]
+}
+
+// backdate moves the last failure into the past so the backoff is over without sleeping
+func backdate(store *MemoryStore, key string, d time.Duration) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	e := store.entries[key]
+	e.attempt.LastFailureAt = e.attempt.LastFailureAt.Add(-d)
+	store.entries[key] = e
+}
+
+func TestLoginInFlightDoesNotHoldBackTheIp(t *testing.T) {
+	guard, _ := newTestGuard()
+
+	_, err := guard.Check("a@example.com", testIp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first login is still comparing the password
+	retryAfter, err := guard.Check("b@example.com", testIp)
+	if err != nil {
+		t.Fatalf("another login from the same ip should not wait for the first one, retry after %s", retryAfter)
+	}
+}
+
+func TestSucceedDoesNotRearmTheIpBackoff(t *testing.T) {
+	guard, store := newTestGuard()
+
+	_, err := guard.Check("a@example.com", testIp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	guard.Fail("a@example.com", testIp)
+
+	backdate(store, ipKey(testIp), 2*time.Second)
+	before := entryOf(store, ipKey(testIp))
+
+	_, err = guard.Check("b@example.com", testIp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	guard.Succeed("b@example.com", testIp)
+
+	after := entryOf(store, ipKey(testIp))
+	if after.attempt != before.attempt || !after.expiresAt.Equal(before.expiresAt) {
+		t.Fatalf("a successful login should leave the ip as it was, got %+v, want %+v", after, before)
+	}
+
+	retryAfter, err := guard.Check("c@example.com", testIp)
+	if err != nil {
+		t.Fatalf("the next login should not back off after a success, retry after %s", retryAfter)
+	}
+}
+
+func TestConcurrentSuccessAndFailureOnSharedIp(t *testing.T) {
+	guard, store := newTestGuard()
+	const logins = 20
+
+	var wg sync.WaitGroup
+	errs := make(chan error, logins)
+	for i := range logins {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := guard.Check(fmt.Sprintf("user%d@example.com", i), testIp)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("logins in flight should not throttle each other, got %v", err)
+		}
+	}
+
+	if inFlight := entryOf(store, ipKey(testIp)).attempt.InFlight; inFlight != logins {
+		t.Fatalf("in flight is %d, want %d", inFlight, logins)
+	}
+
+	for i := range logins {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			email := fmt.Sprintf("user%d@example.com", i)
+			if i%2 == 0 {
+				guard.Succeed(email, testIp)
+			} else {
+				guard.Fail(email, testIp)
+			}
+		}()
+	}
+	wg.Wait()
+
+	attempt := entryOf(store, ipKey(testIp)).attempt
+	if attempt.Failures != logins/2 || attempt.InFlight != 0 {
+		t.Fatalf("ip attempt is %+v, want %d failures and nothing in flight", attempt, logins/2)
+	}
+
+	if !attempt.LockedUntil.IsZero() {
+		t.Fatal("the ip should not be locked under its maximum failed attempts")
+	}
+}
+
+func TestConcurrentLoginsForOneAccountAreLimited(t *testing.T) {
+	guard, _ := newTestGuard()
+	const logins = 20
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for range logins {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := guard.Check("a@example.com", testIp)
+			if err == nil {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != testPolicy.MaxFailedAttempts {
+		t.Fatalf("%d parallel logins were allowed, want at most %d", allowed, testPolicy.MaxFailedAttempts)
+	}
+}
+
+func TestFailedAttemptsLockTheAccount(t *testing.T) {
+	guard, store := newTestGuard()
+
+	for range testPolicy.MaxFailedAttempts {
+		_, err := guard.Check("a@example.com", testIp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		guard.Fail("a@example.com", testIp)
+		backdate(store, accountKey("a@example.com"), maxDelay)
+		backdate(store, ipKey(testIp), maxDelay)
+	}
+
+	retryAfter, err := guard.Check("a@example.com", testIp)
+	if !errors.Is(err, ErrTooManyAttempts) || retryAfter <= maxDelay {
+		t.Fatalf("the account should be locked, got %v with retry after %s", err, retryAfter)
+	}
+
+	// The ip was not locked so other accounts can still log in
+	_, err = guard.Check("b@example.com", testIp)
+	if err != nil {
+		t.Fatalf("other accounts should not be locked, got %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package lockout
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps the failed attempts of a single instance
+type MemoryStore struct {
+	mu          sync.Mutex
+	entries     map[string]entry
+	lastPurgeAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]entry),
+	}
+}
+
+func (m *MemoryStore) Reserve(key string, window time.Duration, waitOf func(attempt Attempt, now time.Time) time.Duration) (Attempt, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.purge(now)
+
+	e := m.get(key, now)
+	wait := e.reserve(now, window, waitOf)
+	if wait > 0 {
+		return e.attempt, wait, nil
+	}
+	m.entries[key] = e
+
+	return e.attempt, 0, nil
+}
+
+func (m *MemoryStore) Release(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.get(key, time.Now())
+	if !e.release() {
+		return nil
+	}
+
+	if e.isEmpty() {
+		delete(m.entries, key)
+		return nil
+	}
+	m.entries[key] = e
+
+	return nil
+}
+
+func (m *MemoryStore) Fail(key string, window time.Duration) (Attempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	e := m.get(key, now)
+	e.fail(now, window)
+	m.entries[key] = e
+
+	return e.attempt, nil
+}
+
+func (m *MemoryStore) Lock(key string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.get(key, time.Now())
+	e.lock(until)
+	m.entries[key] = e
+
+	return nil
+}
+
+func (m *MemoryStore) Reset(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
+
+// get returns an empty entry for unknown or expired keys
+func (m *MemoryStore) get(key string, now time.Time) entry {
+	e, ok := m.entries[key]
+	if !ok || now.After(e.expiresAt) {
+		return entry{}
+	}
+
+	return e
+}
+
+// purge removes expired entries at most once a minute so the map does not grow with every ip that ever failed
+func (m *MemoryStore) purge(now time.Time) {
+	if now.Sub(m.lastPurgeAt) < time.Minute {
+		return
+	}
+	m.lastPurgeAt = now
+
+	for key, e := range m.entries {
+		if now.After(e.expiresAt) {
+			delete(m.entries, key)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"math/rand/v2"
+	"strconv"
+	"time"
+)
+
+const (
+	redisTimeout = 2 * time.Second
+
+	// maxRedisRetries is how many times an update is retried when another instance changed the key first
+	maxRedisRetries = 20
+)
+
+// RedisStore shares the failed attempts between instances, every change is done in a WATCH transaction
+// so the wait check and the increment are atomic like in the MemoryStore
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(addr, password string) *RedisStore {
+	return &RedisStore{
+		client: redis.NewClient(&redis.Options{
+			Addr:     addr,
+			Password: password,
+		}),
+	}
+}
+
+func (r *RedisStore) Reserve(key string, window time.Duration, waitOf func(attempt Attempt, now time.Time) time.Duration) (Attempt, time.Duration, error) {
+	var wait time.Duration
+	attempt, err := r.update(key, func(e *entry, now time.Time) bool {
+		wait = e.reserve(now, window, waitOf)
+		return wait <= 0
+	})
+	if err != nil {
+		return Attempt{}, 0, err
+	}
+
+	return attempt, wait, nil
+}
+
+func (r *RedisStore) Release(key string) error {
+	_, err := r.update(key, func(e *entry, now time.Time) bool {
+		return e.release()
+	})
+	return err
+}
+
+func (r *RedisStore) Fail(key string, window time.Duration) (Attempt, error) {
+	return r.update(key, func(e *entry, now time.Time) bool {
+		e.fail(now, window)
+		return true
+	})
+}
+
+func (r *RedisStore) Lock(key string, until time.Time) error {
+	_, err := r.update(key, func(e *entry, now time.Time) bool {
+		e.lock(until)
+		return true
+	})
+	return err
+}
+
+func (r *RedisStore) Reset(key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	return r.client.Del(ctx, redisKey(key)).Err()
+}
+
+// update reads the entry, lets change decide if it has to be saved, and retries when the key changed in between
+func (r *RedisStore) update(key string, change func(e *entry, now time.Time) bool) (Attempt, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	key = redisKey(key)
+	var attempt Attempt
+	transaction := func(tx *redis.Tx) error {
+		values, err := tx.HGetAll(ctx, key).Result()
+		if err != nil {
+			return err
+		}
+
+		e := decodeEntry(values)
+		if !change(&e, time.Now()) {
+			attempt = e.attempt
+			return nil
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if e.isEmpty() {
+				pipe.Del(ctx, key)
+				return nil
+			}
+
+			pipe.HSet(ctx, key, encodeEntry(e))
+			pipe.PExpireAt(ctx, key, e.expiresAt)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		attempt = e.attempt
+		return nil
+	}
+
+	for range maxRedisRetries {
+		err := r.client.Watch(ctx, transaction, key)
+		if errors.Is(err, redis.TxFailedErr) {
+			// Spread the retries so the instances that lost don't collide again
+			time.Sleep(time.Duration(1+rand.IntN(10)) * time.Millisecond)
+			continue
+		}
+
+		return attempt, err
+	}
+
+	return Attempt{}, errors.New("too many concurrent login attempts, try again")
+}
+
+func redisKey(key string) string {
+	return "lockout:" + key
+}
+
+// Times are saved as unix milliseconds, 0 is the zero time
+func encodeEntry(e entry) map[string]any {
+	return map[string]any{
+		"failures":        e.attempt.Failures,
+		"in_flight":       e.attempt.InFlight,
+		"last_failure_at": unixMilli(e.attempt.LastFailureAt),
+		"locked_until":    unixMilli(e.attempt.LockedUntil),
+		"expires_at":      unixMilli(e.expiresAt),
+	}
+}
+
+func decodeEntry(values map[string]string) entry {
+	number := func(field string) int64 {
+		value, _ := strconv.ParseInt(values[field], 10, 64)
+		return value
+	}
+
+	return entry{
+		attempt: Attempt{
+			Failures:      int(number("failures")),
+			InFlight:      int(number("in_flight")),
+			LastFailureAt: fromUnixMilli(number("last_failure_at")),
+			LockedUntil:   fromUnixMilli(number("locked_until")),
+		},
+		expiresAt: fromUnixMilli(number("expires_at")),
+	}
+}
+
+func unixMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.UnixMilli()
+}
+
+func fromUnixMilli(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+
+	return time.UnixMilli(ms)
+}
@@ -0,0 +1,184 @@
+package lockout
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestRedisStore(t *testing.T) (*RedisStore, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	return NewRedisStore(server.Addr(), ""), server
+}
+
+// Both stores should make the same decisions so switching LOGIN_LOCKOUT_DRIVER does not change the lockout
+func TestStores(t *testing.T) {
+	redisStore, _ := newTestRedisStore(t)
+	stores := map[string]Store{
+		"memory": NewMemoryStore(),
+		"redis":  redisStore,
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			wait := waitOf(testPolicy.MaxFailedAttempts)
+
+			attempt, retryAfter, err := store.Reserve("ip:1", time.Minute, wait)
+			if err != nil || retryAfter > 0 || attempt.InFlight != 1 {
+				t.Fatalf("first attempt should be reserved, got %+v with retry after %s and %v", attempt, retryAfter, err)
+			}
+
+			err = store.Release("ip:1")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			attempt, retryAfter, err = store.Reserve("ip:1", time.Minute, wait)
+			if err != nil || retryAfter > 0 || attempt != (Attempt{InFlight: 1}) {
+				t.Fatalf("a released attempt should leave nothing behind, got %+v with retry after %s and %v", attempt, retryAfter, err)
+			}
+
+			attempt, err = store.Fail("ip:1", time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if attempt.Failures != 1 || attempt.InFlight != 0 || attempt.LastFailureAt.IsZero() {
+				t.Fatalf("failed attempt is %+v, want 1 failure and nothing in flight", attempt)
+			}
+
+			_, retryAfter, err = store.Reserve("ip:1", time.Minute, wait)
+			if err != nil || retryAfter <= 0 {
+				t.Fatalf("the next attempt should back off after a failure, got retry after %s and %v", retryAfter, err)
+			}
+
+			err = store.Lock("ip:1", time.Now().Add(time.Hour))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, retryAfter, err = store.Reserve("ip:1", time.Minute, wait)
+			if err != nil || retryAfter < 59*time.Minute {
+				t.Fatalf("a locked key should wait until it's unlocked, got retry after %s and %v", retryAfter, err)
+			}
+
+			err = store.Reset("ip:1")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, retryAfter, err = store.Reserve("ip:1", time.Minute, wait)
+			if err != nil || retryAfter > 0 {
+				t.Fatalf("a reset key should start over, got retry after %s and %v", retryAfter, err)
+			}
+		})
+	}
+}
+
+func TestRedisStoreConcurrentLoginsForOneAccountAreLimited(t *testing.T) {
+	store, _ := newTestRedisStore(t)
+	guard := NewGuard(store, testPolicy)
+	const logins = 20
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for range logins {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := guard.Check("a@example.com", testIp)
+			if err == nil {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != testPolicy.MaxFailedAttempts {
+		t.Fatalf("%d parallel logins were allowed, want at most %d", allowed, testPolicy.MaxFailedAttempts)
+	}
+}
+
+func TestRedisStoreConcurrentSuccessAndFailureOnSharedIp(t *testing.T) {
+	store, server := newTestRedisStore(t)
+	guard := NewGuard(store, testPolicy)
+	const logins = 20
+
+	var wg sync.WaitGroup
+	errs := make(chan error, logins)
+	for i := range logins {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := guard.Check(fmt.Sprintf("user%d@example.com", i), testIp)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("logins in flight should not throttle each other, got %v", err)
+		}
+	}
+
+	for i := range logins {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			email := fmt.Sprintf("user%d@example.com", i)
+			if i%2 == 0 {
+				guard.Succeed(email, testIp)
+			} else {
+				guard.Fail(email, testIp)
+			}
+		}()
+	}
+	wg.Wait()
+
+	key := redisKey(ipKey(testIp))
+	if failures := server.HGet(key, "failures"); failures != fmt.Sprint(logins/2) {
+		t.Fatalf("ip failures are %s, want %d", failures, logins/2)
+	}
+
+	if inFlight := server.HGet(key, "in_flight"); inFlight != "0" {
+		t.Fatalf("ip in flight is %s, want 0", inFlight)
+	}
+}
+
+func TestRedisStoreExpiresEntries(t *testing.T) {
+	store, server := newTestRedisStore(t)
+
+	_, err := store.Fail("ip:1", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !server.Exists(redisKey("ip:1")) {
+		t.Fatal("the failure should be saved")
+	}
+
+	server.SetTime(time.Now().Add(2 * time.Minute))
+	server.FastForward(2 * time.Minute)
+	if server.Exists(redisKey("ip:1")) {
+		t.Fatal("the failure should expire after the window")
+	}
+}
+
+func TestRedisStoreDown(t *testing.T) {
+	store, server := newTestRedisStore(t)
+	server.Close()
+
+	_, err := NewGuard(store, testPolicy).Check("a@example.com", testIp)
+	if err == nil || errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("an unreachable redis should return its error so the login is let through, got %v", err)
+	}
+}
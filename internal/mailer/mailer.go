@@ -0,0 +1,14 @@
+package mailer
+
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// New returns the mailer selected by MAIL_DRIVER, either smtp (default) or memory
+func New(driver string) Mailer {
+	if driver == "memory" {
+		return NewMemoryMailer()
+	}
+
+	return NewSMTPMailer()
+}
@@ -0,0 +1,49 @@
+package mailer
+
+import (
+	"log"
+	"sync"
+)
+
+type (
+	Message struct {
+		To      string
+		Subject string
+		Body    string
+	}
+
+	// MemoryMailer keeps the sent messages instead of sending them
+	// Used for tests and local runs without an SMTP server
+	MemoryMailer struct {
+		mu       sync.Mutex
+		messages []Message
+	}
+)
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(to, subject, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, Message{
+		To:      to,
+		Subject: subject,
+		Body:    body,
+	})
+
+	log.Println("INFO: in memory mail sent to", to, "with subject", subject)
+	return nil
+}
+
+// Messages returns a copy of every sent message
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	messages := make([]Message, len(m.messages))
+	copy(messages, m.messages)
+	return messages
+}
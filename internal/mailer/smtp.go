@@ -0,0 +1,44 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"net/smtp"
+	"os"
+	"strings"
+)
+
+type SMTPMailer struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+// NewSMTPMailer reads the MAIL_* environment variables
+// Username and password can be empty for local SMTP servers like MailHog
+func NewSMTPMailer() Mailer {
+	return &SMTPMailer{
+		host:     os.Getenv("MAIL_HOST"),
+		port:     os.Getenv("MAIL_PORT"),
+		username: os.Getenv("MAIL_USERNAME"),
+		password: os.Getenv("MAIL_PASSWORD"),
+		from:     os.Getenv("MAIL_FROM"),
+	}
+}
+
+func (m SMTPMailer) Send(to, subject, body string) error {
+	// Prevents injecting extra headers through the recipient or subject
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return errors.New("recipient and subject must not contain new lines")
+	}
+
+	var auth smtp.Auth
+	if strings.TrimSpace(m.username) != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n", m.from, to, subject, body)
+	return smtp.SendMail(m.host+":"+m.port, auth, m.from, []string{to}, []byte(message))
+}
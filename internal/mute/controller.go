@@ -0,0 +1,128 @@
+package mute
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"social-media-application/internal/paging"
+	"social-media-application/middlewares"
+	"strconv"
+)
+
+type (
+	Controller interface {
+		save(ctx *gin.Context)
+
+		getAll(ctx *gin.Context)
+
+		delete(ctx *gin.Context)
+
+		RegisterRoutes(e *gin.Engine)
+	}
+
+	ControllerImpl struct {
+		service Service
+	}
+)
+
+func NewController(service Service) Controller {
+	return &ControllerImpl{
+		service: service,
+	}
+}
+
+func (c ControllerImpl) RegisterRoutes(e *gin.Engine) {
+	r := e.Group("/users/mutes", middleware.JWT)
+	{
+		r.POST("/:id", c.save)
+		r.GET("", c.getAll)
+		r.DELETE("/:id", c.delete)
+	}
+}
+
+func (c ControllerImpl) save(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "save failed " + err.Error(),
+		})
+		return
+	}
+
+	mutedId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "save failed " + err.Error(),
+		})
+		return
+	}
+
+	id, err := c.service.save(sub, mutedId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "save failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, id)
+}
+
+func (c ControllerImpl) getAll(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all failed " + err.Error(),
+		})
+		return
+	}
+
+	page := ctx.DefaultQuery("page", "1")
+	pageSize := ctx.DefaultQuery("pageSize", "10")
+	field := ctx.DefaultQuery("field", "created_at")
+	sortBy := ctx.DefaultQuery("sortBy", "DESC")
+	request, err := paging.NewPageRequestStr(page, pageSize, field, sortBy)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all failed " + err.Error(),
+		})
+		return
+	}
+
+	mutes, err := c.service.getAll(sub, request)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "get all failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, mutes)
+}
+
+func (c ControllerImpl) delete(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "delete failed " + err.Error(),
+		})
+		return
+	}
+
+	mutedId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "delete failed " + err.Error(),
+		})
+		return
+	}
+
+	_, err = c.service.delete(sub, mutedId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "delete failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
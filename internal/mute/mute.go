@@ -0,0 +1,10 @@
+package mute
+
+import "time"
+
+type Mute struct {
+	Id        int       `json:"id" db:"id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	MuterId   int       `json:"muter_id" db:"muter_id"`
+	MutedId   int       `json:"muted_id" db:"muted_id"`
+}
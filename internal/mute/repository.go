@@ -0,0 +1,102 @@
+package mute
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"log"
+	"social-media-application/internal/paging"
+	"social-media-application/utils"
+)
+
+type (
+	Repository interface {
+		save(muterId, mutedId int) (id int64, err error)
+
+		findAll(muterId int, request *paging.PageRequest) (*paging.Page[Mute], error)
+
+		delete(muterId, mutedId int) (affectedRows int64, err error)
+
+		isAlreadyMuted(muterId, mutedId int) (bool, error)
+	}
+
+	RepositoryImpl struct {
+		*sqlx.DB
+	}
+)
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &RepositoryImpl{
+		DB: db,
+	}
+}
+
+func (repository RepositoryImpl) save(muterId, mutedId int) (id int64, err error) {
+	result, err := repository.NamedExec("INSERT INTO mute (muter_id, muted_id) VALUES (:muterId, :mutedId)", map[string]any{
+		"muterId": muterId,
+		"mutedId": mutedId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	id, err = result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (repository RepositoryImpl) findAll(muterId int, request *paging.PageRequest) (*paging.Page[Mute], error) {
+	if !utils.IsInDBTag(request.Field, Mute{}) {
+		request.Field = "created_at"
+		log.Println("WARNING: field is not in database! defaulted to", request.Field)
+	}
+
+	if !utils.IsInSortingOrder(request.SortBy) {
+		request.SortBy = "DESC"
+		log.Println("WARNING: sortBy is not valid! defaulted to", request.SortBy)
+	}
+
+	var total int
+	err := repository.Get(&total, "SELECT COUNT(*) FROM mute WHERE muter_id = ?", muterId)
+	if err != nil {
+		return nil, err
+	}
+
+	mutes := make([]Mute, 0, request.PageSize)
+	query := fmt.Sprintf("SELECT * FROM mute WHERE muter_id = ? ORDER BY %s %s LIMIT ? OFFSET ?", request.Field, request.SortBy)
+	err = repository.Select(&mutes, query, muterId, request.PageSize, request.Offset())
+	if err != nil {
+		return nil, err
+	}
+
+	return paging.NewPage(mutes, request, total), nil
+}
+
+func (repository RepositoryImpl) delete(muterId, mutedId int) (affectedRows int64, err error) {
+	result, err := repository.NamedExec("DELETE FROM mute WHERE muter_id = :muterId AND muted_id = :mutedId", map[string]any{
+		"muterId": muterId,
+		"mutedId": mutedId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
+func (repository RepositoryImpl) isAlreadyMuted(muterId, mutedId int) (bool, error) {
+	var exists bool
+	err := repository.Get(&exists, "SELECT EXISTS(SELECT 1 FROM mute WHERE muter_id = ? AND muted_id = ?)", muterId, mutedId)
+	if err != nil {
+		return exists, err
+	}
+
+	return exists, nil
+}
@@ -0,0 +1,90 @@
+package mute
+
+import (
+	"errors"
+	"social-media-application/internal/paging"
+)
+
+type (
+	Service interface {
+		save(muterId, mutedId int) (id int64, err error)
+
+		getAll(muterId int, request *paging.PageRequest) (*paging.Page[Mute], error)
+
+		delete(muterId, mutedId int) (affectedRows int64, err error)
+	}
+
+	ServiceImpl struct {
+		repository Repository
+	}
+)
+
+func NewService(repository Repository) Service {
+	return &ServiceImpl{
+		repository: repository,
+	}
+}
+
+func (s ServiceImpl) save(muterId, mutedId int) (id int64, err error) {
+	if muterId <= 0 {
+		return 0, errors.New("muter id is required")
+	}
+
+	if mutedId <= 0 {
+		return 0, errors.New("muted id is required")
+	}
+
+	if muterId == mutedId {
+		return 0, errors.New("cannot mute yourself")
+	}
+
+	isAlreadyMuted, err := s.repository.isAlreadyMuted(muterId, mutedId)
+	if err != nil {
+		return 0, err
+	}
+
+	if isAlreadyMuted {
+		return 0, errors.New("user already muted")
+	}
+
+	id, err = s.repository.save(muterId, mutedId)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (s ServiceImpl) getAll(muterId int, request *paging.PageRequest) (*paging.Page[Mute], error) {
+	if muterId <= 0 {
+		return nil, errors.New("muter id is required")
+	}
+
+	mutes, err := s.repository.findAll(muterId, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return mutes, nil
+}
+
+func (s ServiceImpl) delete(muterId, mutedId int) (affectedRows int64, err error) {
+	if muterId <= 0 {
+		return 0, errors.New("muter id is required")
+	}
+
+	if mutedId <= 0 {
+		return 0, errors.New("muted id is required")
+	}
+
+	affectedRows, err = s.repository.delete(muterId, mutedId)
+	if err != nil {
+		return 0, err
+	}
+
+	if affectedRows <= 0 {
+		return 0, errors.New("current user did not mute this user")
+	}
+
+	return affectedRows, nil
+}
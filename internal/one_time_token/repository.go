@@ -0,0 +1,114 @@
+package one_time_token
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+var ErrInvalidToken = errors.New("token is invalid or expired")
+
+type (
+	Repository interface {
+		save(userId int, tokenHash string, expiresAt time.Time) (id int64, err error)
+
+		use(tokenHash string, then func(tx *sqlx.Tx, token Token) error) error
+
+		markAllUsedBy(userId int) (affectedRows int64, err error)
+	}
+
+	// RepositoryImpl table is one of the token tables (password_reset, email_verification), they all have the same columns
+	RepositoryImpl struct {
+		*sqlx.DB
+		table string
+	}
+)
+
+func NewRepository(db *sqlx.DB, table string) Repository {
+	return &RepositoryImpl{
+		DB:    db,
+		table: table,
+	}
+}
+
+func (repository RepositoryImpl) save(userId int, tokenHash string, expiresAt time.Time) (id int64, err error) {
+	result, err := repository.NamedExec(fmt.Sprintf("INSERT INTO %s (token_hash, expires_at, user_id) VALUES (:tokenHash, :expiresAt, :userId)", repository.table), map[string]any{
+		"tokenHash": tokenHash,
+		"expiresAt": expiresAt,
+		"userId":    userId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	id, err = result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// use marks the token as used and runs then in the same transaction
+// the token is locked so two concurrent requests cannot both use it, and it stays unused when then fails
+func (repository RepositoryImpl) use(tokenHash string, then func(tx *sqlx.Tx, token Token) error) error {
+	tx, err := repository.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func(tx *sqlx.Tx) {
+		err := tx.Rollback()
+		if err != nil {
+			return
+		}
+	}(tx)
+
+	var token Token
+	err = tx.Get(&token, fmt.Sprintf("SELECT * FROM %s WHERE token_hash = ? FOR UPDATE", repository.table), tokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidToken
+		}
+
+		return err
+	}
+
+	if token.IsUsed() || token.IsExpired() {
+		return ErrInvalidToken
+	}
+
+	_, err = tx.Exec(fmt.Sprintf("UPDATE %s SET used_at = NOW() WHERE id = ?", repository.table), token.Id)
+	if err != nil {
+		return err
+	}
+
+	err = then(tx, token)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository RepositoryImpl) markAllUsedBy(userId int) (affectedRows int64, err error) {
+	result, err := repository.NamedExec(fmt.Sprintf("UPDATE %s SET used_at = NOW() WHERE user_id = :userId AND used_at IS NULL", repository.table), map[string]any{
+		"userId": userId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
@@ -0,0 +1,61 @@
+package one_time_token
+
+import (
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"social-media-application/utils"
+	"strings"
+	"time"
+)
+
+type (
+	Service interface {
+		Issue(userId int, expiresIn time.Duration) (token string, err error) // used by password reset and email verification, invalidates the previous tokens
+		Use(token string, then func(tx *sqlx.Tx, userId int) error) error    // used by password reset and email verification
+	}
+
+	ServiceImpl struct {
+		repository Repository
+	}
+)
+
+func NewService(repository Repository) Service {
+	return &ServiceImpl{
+		repository: repository,
+	}
+}
+
+func (s ServiceImpl) Issue(userId int, expiresIn time.Duration) (token string, err error) {
+	if userId <= 0 {
+		return "", errors.New("user id is required")
+	}
+
+	token, err = utils.GenerateToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	// Only the latest issued token can be used
+	_, err = s.repository.markAllUsedBy(userId)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = s.repository.save(userId, utils.HashToken(token), time.Now().Add(expiresIn))
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// Use returns ErrInvalidToken for unknown, used, and expired tokens
+func (s ServiceImpl) Use(token string, then func(tx *sqlx.Tx, userId int) error) error {
+	if strings.TrimSpace(token) == "" {
+		return errors.New("token is required")
+	}
+
+	return s.repository.use(utils.HashToken(token), func(tx *sqlx.Tx, token Token) error {
+		return then(tx, token.UserId)
+	})
+}
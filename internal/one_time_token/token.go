@@ -0,0 +1,25 @@
+package one_time_token
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Token is a hashed, single use, and expiring token sent by email
+// only the sha256 is stored so a leaked database cannot be used to reset passwords or verify emails
+type Token struct {
+	Id        int          `json:"id" db:"id"`
+	CreatedAt time.Time    `json:"created_at" db:"created_at"`
+	TokenHash string       `json:"-" db:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at" db:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at" db:"used_at"`
+	UserId    int          `json:"user_id" db:"user_id"`
+}
+
+func (t Token) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
+
+func (t Token) IsUsed() bool {
+	return t.UsedAt.Valid // If theres a value it is used
+}
@@ -0,0 +1,110 @@
+package paging
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cursor is the position of the last element of a keyset page.
+// CreatedAt and Id are used together because created_at alone is not unique.
+type Cursor struct {
+	CreatedAt time.Time
+	Id        int
+}
+
+type CursorRequest struct {
+	Cursor   *Cursor `json:"-"`
+	PageSize int     `json:"page_size"`
+}
+
+type CursorPage[T any] struct {
+	Content    []T    `json:"content"`
+	PageSize   int    `json:"page_size"`
+	NextCursor string `json:"next_cursor"`
+	HasNext    bool   `json:"has_next"`
+}
+
+func NewCursorRequestStr(cursor, pageSize string) (*CursorRequest, error) {
+	pageSizeInt, err := strconv.Atoi(pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if pageSizeInt <= 0 {
+		return nil, errors.New("page size is required")
+	}
+
+	// Empty cursor means start from the newest element
+	if strings.TrimSpace(cursor) == "" {
+		return &CursorRequest{
+			PageSize: pageSizeInt,
+		}, nil
+	}
+
+	decoded, err := DecodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CursorRequest{
+		Cursor:   decoded,
+		PageSize: pageSizeInt,
+	}, nil
+}
+
+// NewCursorPage expects the repository to fetch PageSize + 1 elements
+// so the extra element tells if there's a next page without another COUNT query.
+func NewCursorPage[T any](content []T, request *CursorRequest, cursorOf func(T) Cursor) *CursorPage[T] {
+	hasNext := len(content) > request.PageSize
+	if hasNext {
+		content = content[:request.PageSize]
+	}
+
+	var nextCursor string
+	if hasNext {
+		nextCursor = cursorOf(content[len(content)-1]).Encode()
+	}
+
+	return &CursorPage[T]{
+		Content:    content,
+		PageSize:   request.PageSize,
+		NextCursor: nextCursor,
+		HasNext:    hasNext,
+	}
+}
+
+func (c Cursor) Encode() string {
+	raw := fmt.Sprintf("%d:%d", c.CreatedAt.UnixNano(), c.Id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(cursor string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.New("cursor is invalid")
+	}
+
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 2 {
+		return nil, errors.New("cursor is invalid")
+	}
+
+	createdAt, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, errors.New("cursor is invalid")
+	}
+
+	id, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, errors.New("cursor is invalid")
+	}
+
+	return &Cursor{
+		CreatedAt: time.Unix(0, createdAt).UTC(),
+		Id:        id,
+	}, nil
+}
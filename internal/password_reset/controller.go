@@ -0,0 +1,86 @@
+package password_reset
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"social-media-application/internal/audit"
+)
+
+type (
+	Controller interface {
+		request(ctx *gin.Context)
+		reset(ctx *gin.Context)
+
+		RegisterRoutes(e *gin.Engine)
+	}
+
+	ControllerImpl struct {
+		service Service
+	}
+)
+
+func NewController(service Service) Controller {
+	return &ControllerImpl{
+		service: service,
+	}
+}
+
+func (c ControllerImpl) RegisterRoutes(e *gin.Engine) {
+	r := e.Group("/users/password")
+	{
+		// Public
+		r.POST("/forgot", c.request)
+		r.POST("/reset", c.reset)
+	}
+}
+
+func (c ControllerImpl) request(ctx *gin.Context) {
+	request := struct {
+		Email string `json:"email" binding:"required"`
+	}{}
+
+	if err := ctx.ShouldBind(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "forgot password failed " + err.Error(),
+		})
+		return
+	}
+
+	err := c.service.request(request.Email)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "forgot password failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message": "if the email is registered a password reset link was sent",
+	})
+}
+
+func (c ControllerImpl) reset(ctx *gin.Context) {
+	request := struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}{}
+
+	if err := ctx.ShouldBind(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "reset password failed " + err.Error(),
+		})
+		return
+	}
+
+	err := c.service.reset(request.Token, request.Password, audit.NewOrigin(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "reset password failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "password reset successful",
+	})
+}
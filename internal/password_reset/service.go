@@ -0,0 +1,145 @@
+package password_reset
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"log"
+	"net/url"
+	"os"
+	"social-media-application/internal/audit"
+	"social-media-application/internal/lockout"
+	"social-media-application/internal/mailer"
+	ott "social-media-application/internal/one_time_token"
+	pat "social-media-application/internal/personal_access_token"
+	"social-media-application/internal/refresh"
+	"social-media-application/internal/user"
+	pd "social-media-application/internal/user/password"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultExpirationInMinute is used when PASSWORD_RESET_EXPIRATION_IN_MINUTE is not set
+const defaultExpirationInMinute = 30
+
+type (
+	Service interface {
+		request(email string) error
+		reset(token, newPassword string, origin audit.Origin) error
+	}
+
+	ServiceImpl struct {
+		tokenService   ott.Service
+		userService    user.Service
+		refreshService refresh.Service
+		patService     pat.Service
+		mailer         mailer.Mailer
+		loginGuard     *lockout.Guard
+		passwordPolicy pd.Policy
+	}
+)
+
+func NewService(tokenService ott.Service, userService user.Service, refreshService refresh.Service, patService pat.Service, mailer mailer.Mailer, loginGuard *lockout.Guard, passwordPolicy pd.Policy) Service {
+	return &ServiceImpl{
+		tokenService:   tokenService,
+		userService:    userService,
+		refreshService: refreshService,
+		patService:     patService,
+		mailer:         mailer,
+		loginGuard:     loginGuard,
+		passwordPolicy: passwordPolicy,
+	}
+}
+
+// request returns no error for unknown, social only, and deactivated users
+// so the endpoint cannot be used to check which emails are registered
+func (s ServiceImpl) request(email string) error {
+	if strings.TrimSpace(email) == "" {
+		return errors.New("email is required")
+	}
+
+	u, err := s.userService.GetByEmail(email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+
+		return err
+	}
+
+	if !u.IsActive || strings.TrimSpace(u.Password) == "" {
+		return nil
+	}
+
+	// Sent in the background so the response takes as long for registered emails as for unknown ones
+	go s.sendResetLink(u.Id, u.Email)
+
+	return nil
+}
+
+// sendResetLink failures are only logged since the request already responded
+func (s ServiceImpl) sendResetLink(userId int, email string) {
+	expiration := expirationInMinute()
+	token, err := s.tokenService.Issue(userId, time.Duration(expiration)*time.Minute)
+	if err != nil {
+		log.Println("WARNING: issuing password reset token failed for user", userId, err)
+		return
+	}
+
+	link := os.Getenv("PASSWORD_RESET_URL") + "?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf("Use the link below to reset your password. It expires in %d minutes.\n\n%s\n\nIf you did not request this you can ignore this email.", expiration, link)
+	err = s.mailer.Send(email, "Reset your password", body)
+	if err != nil {
+		log.Println("WARNING: sending password reset email failed for user", userId, err)
+	}
+}
+
+func (s ServiceImpl) reset(token, newPassword string, origin audit.Origin) error {
+	// Checked first so a weak password is rejected before the token is locked
+	err := s.passwordPolicy.Validate(newPassword)
+	if err != nil {
+		return err
+	}
+
+	// The token proves who is resetting the password
+	userId := 0
+	err = s.tokenService.Use(token, func(tx *sqlx.Tx, tokenUserId int) error {
+		userId = tokenUserId
+		origin = origin.As(userId)
+		return s.userService.ResetPassword(tx, userId, newPassword, origin)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Logs out every device and revokes the personal access tokens in case the account was compromised
+	_, err = s.refreshService.RevokeAllBy(userId, origin)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.patService.RevokeAllBy(userId)
+	if err != nil {
+		return err
+	}
+
+	// Proving access to the email unlocks the account
+	u, err := s.userService.GetById(userId)
+	if err != nil {
+		return err
+	}
+	s.loginGuard.Unlock(u.Email)
+
+	return nil
+}
+
+func expirationInMinute() int {
+	expiration, err := strconv.Atoi(os.Getenv("PASSWORD_RESET_EXPIRATION_IN_MINUTE"))
+	if err != nil || expiration <= 0 {
+		return defaultExpirationInMinute
+	}
+
+	return expiration
+}
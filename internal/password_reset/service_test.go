@@ -0,0 +1,89 @@
+package password_reset
+
+import (
+	"database/sql"
+	"social-media-application/internal/mailer"
+	ott "social-media-application/internal/one_time_token"
+	"social-media-application/internal/user"
+	pd "social-media-application/internal/user/password"
+	"testing"
+	"time"
+)
+
+type fakeUserService struct {
+	user.Service
+	users map[string]user.User
+}
+
+func (f fakeUserService) GetByEmail(email string) (user.User, error) {
+	u, ok := f.users[email]
+	if !ok {
+		return user.User{}, sql.ErrNoRows
+	}
+
+	return u, nil
+}
+
+type fakeTokenService struct {
+	ott.Service
+}
+
+func (f fakeTokenService) Issue(userId int, expiresIn time.Duration) (string, error) {
+	return "token", nil
+}
+
+func newTestService(memoryMailer mailer.Mailer) Service {
+	userService := fakeUserService{users: map[string]user.User{
+		"local@example.com":  {Id: 1, Email: "local@example.com", Password: "hash", IsActive: true},
+		"social@example.com": {Id: 2, Email: "social@example.com", IsActive: true},
+	}}
+
+	return NewService(fakeTokenService{}, userService, nil, nil, memoryMailer, nil, pd.Policy{})
+}
+
+// waitForMessages the reset link is sent in the background
+func waitForMessages(memoryMailer *mailer.MemoryMailer, count int) []mailer.Message {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		messages := memoryMailer.Messages()
+		if len(messages) >= count {
+			return messages
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	return memoryMailer.Messages()
+}
+
+func TestRequestMailsRegisteredEmails(t *testing.T) {
+	memoryMailer := mailer.NewMemoryMailer()
+	service := newTestService(memoryMailer)
+
+	err := service.request("local@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := waitForMessages(memoryMailer, 1)
+	if len(messages) != 1 || messages[0].To != "local@example.com" {
+		t.Fatalf("got %+v, want one message to local@example.com", messages)
+	}
+}
+
+func TestRequestDoesNotRevealUnknownEmails(t *testing.T) {
+	memoryMailer := mailer.NewMemoryMailer()
+	service := newTestService(memoryMailer)
+
+	for _, email := range []string{"unknown@example.com", "social@example.com"} {
+		err := service.request(email)
+		if err != nil {
+			t.Fatalf("%s got %v, want the same response as registered emails", email, err)
+		}
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if messages := memoryMailer.Messages(); len(messages) != 0 {
+		t.Fatalf("got %d messages, want none", len(messages))
+	}
+}
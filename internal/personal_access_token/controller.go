@@ -0,0 +1,138 @@
+package personal_access_token
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"social-media-application/internal/paging"
+	"social-media-application/middlewares"
+	"strconv"
+)
+
+type (
+	Controller interface {
+		save(ctx *gin.Context)
+
+		getAll(ctx *gin.Context)
+
+		revoke(ctx *gin.Context)
+
+		RegisterRoutes(e *gin.Engine)
+	}
+
+	ControllerImpl struct {
+		service Service
+	}
+)
+
+func NewController(service Service) Controller {
+	return &ControllerImpl{
+		service: service,
+	}
+}
+
+func (c ControllerImpl) RegisterRoutes(e *gin.Engine) {
+	// Not scoped so a personal access token cannot create another one
+	r := e.Group("/users/personal-access-tokens", middleware.JWT)
+	{
+		r.POST("", c.save)
+		r.GET("", c.getAll)
+		r.DELETE("/:id", c.revoke)
+	}
+}
+
+func (c ControllerImpl) save(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "save failed " + err.Error(),
+		})
+		return
+	}
+
+	request := struct {
+		Name          string   `json:"name" binding:"required"`
+		Scopes        []string `json:"scopes" binding:"required"`
+		ExpiresInDays int      `json:"expires_in_days" binding:"required"`
+	}{}
+
+	if err := ctx.ShouldBind(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "save failed " + err.Error(),
+		})
+		return
+	}
+
+	id, token, err := c.service.save(sub, request.Name, request.Scopes, request.ExpiresInDays)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "save failed " + err.Error(),
+		})
+		return
+	}
+
+	// The token is only shown once
+	ctx.JSON(http.StatusCreated, gin.H{
+		"id":    id,
+		"token": token,
+	})
+}
+
+func (c ControllerImpl) getAll(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all failed " + err.Error(),
+		})
+		return
+	}
+
+	page := ctx.DefaultQuery("page", "1")
+	pageSize := ctx.DefaultQuery("pageSize", "10")
+	field := ctx.DefaultQuery("field", "created_at")
+	sortBy := ctx.DefaultQuery("sortBy", "DESC")
+	request, err := paging.NewPageRequestStr(page, pageSize, field, sortBy)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all failed " + err.Error(),
+		})
+		return
+	}
+
+	tokens, err := c.service.getAllBy(sub, request)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "get all failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+func (c ControllerImpl) revoke(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "revoke failed " + err.Error(),
+		})
+		return
+	}
+
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "revoke failed " + err.Error(),
+		})
+		return
+	}
+
+	_, err = c.service.revoke(sub, id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "revoke failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,78 @@
+package personal_access_token
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"social-media-application/middlewares"
+	"strings"
+	"time"
+)
+
+// Prefix is shared with the middlewares so they can tell personal access tokens apart from access tokens
+const Prefix = middleware.PersonalAccessTokenPrefix
+
+// Scopes that can be granted, reads are GET requests and writes are everything else
+const (
+	POSTS_READ      = "posts:read"
+	POSTS_WRITE     = "posts:write"
+	COMMENTS_READ   = "comments:read"
+	COMMENTS_WRITE  = "comments:write"
+	REACTIONS_READ  = "reactions:read"
+	REACTIONS_WRITE = "reactions:write"
+)
+
+var AllScopes = []string{POSTS_READ, POSTS_WRITE, COMMENTS_READ, COMMENTS_WRITE, REACTIONS_READ, REACTIONS_WRITE}
+
+// PersonalAccessToken only stores the sha256 of the token, the token is only shown once when created
+type PersonalAccessToken struct {
+	Id         int          `json:"id" db:"id"`
+	CreatedAt  time.Time    `json:"created_at" db:"created_at"`
+	Name       string       `json:"name" db:"name"`
+	Prefix     string       `json:"prefix" db:"prefix"`
+	TokenHash  string       `json:"-" db:"token_hash"`
+	Scopes     Scopes       `json:"scopes" db:"scopes"`
+	ExpiresAt  time.Time    `json:"expires_at" db:"expires_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at" db:"last_used_at"`
+	RevokedAt  sql.NullTime `json:"revoked_at" db:"revoked_at"`
+	UserId     int          `json:"user_id" db:"user_id"`
+}
+
+func (t PersonalAccessToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
+
+func (t PersonalAccessToken) IsRevoked() bool {
+	return t.RevokedAt.Valid // If theres a value it is revoked
+}
+
+// Scopes is saved as a comma separated string
+type Scopes []string
+
+func (s Scopes) Value() (driver.Value, error) {
+	return strings.Join(s, ","), nil
+}
+
+func (s *Scopes) Scan(value any) error {
+	var raw string
+	switch v := value.(type) {
+	case []byte:
+		raw = string(v)
+	case string:
+		raw = v
+	case nil:
+		*s = Scopes{}
+		return nil
+	default:
+		return errors.New("scopes is not a string")
+	}
+
+	*s = Scopes{}
+	for _, scope := range strings.Split(raw, ",") {
+		if strings.TrimSpace(scope) != "" {
+			*s = append(*s, strings.TrimSpace(scope))
+		}
+	}
+
+	return nil
+}
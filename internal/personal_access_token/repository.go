@@ -0,0 +1,148 @@
+package personal_access_token
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"log"
+	"social-media-application/internal/paging"
+	"social-media-application/utils"
+	"time"
+)
+
+type (
+	Repository interface {
+		save(userId int, name, prefix, tokenHash string, scopes Scopes, expiresAt time.Time) (id int64, err error)
+
+		findBy(tokenHash string) (PersonalAccessToken, error)
+		findAllBy(userId int, request *paging.PageRequest) (*paging.Page[PersonalAccessToken], error)
+
+		isUserActive(userId int) (bool, error)
+
+		updateLastUsed(id int) error
+		revoke(userId, id int) (affectedRows int64, err error)
+		revokeAllBy(userId int) (affectedRows int64, err error)
+	}
+
+	RepositoryImpl struct {
+		*sqlx.DB
+	}
+)
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &RepositoryImpl{
+		DB: db,
+	}
+}
+
+func (repository RepositoryImpl) save(userId int, name, prefix, tokenHash string, scopes Scopes, expiresAt time.Time) (id int64, err error) {
+	result, err := repository.NamedExec("INSERT INTO personal_access_token (name, prefix, token_hash, scopes, expires_at, user_id) VALUES (:name, :prefix, :tokenHash, :scopes, :expiresAt, :userId)", map[string]any{
+		"name":      name,
+		"prefix":    prefix,
+		"tokenHash": tokenHash,
+		"scopes":    scopes,
+		"expiresAt": expiresAt,
+		"userId":    userId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	id, err = result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (repository RepositoryImpl) findBy(tokenHash string) (PersonalAccessToken, error) {
+	var token PersonalAccessToken
+	err := repository.Get(&token, "SELECT * FROM personal_access_token WHERE token_hash = ?", tokenHash)
+	if err != nil {
+		return PersonalAccessToken{}, err
+	}
+
+	return token, nil
+}
+
+func (repository RepositoryImpl) findAllBy(userId int, request *paging.PageRequest) (*paging.Page[PersonalAccessToken], error) {
+	if !utils.IsInDBTag(request.Field, PersonalAccessToken{}) {
+		request.Field = "created_at"
+		log.Println("WARNING: field is not in database! defaulted to", request.Field)
+	}
+
+	if !utils.IsInSortingOrder(request.SortBy) {
+		request.SortBy = "DESC"
+		log.Println("WARNING: sortBy is not valid! defaulted to", request.SortBy)
+	}
+
+	var total int
+	err := repository.Get(&total, "SELECT COUNT(*) FROM personal_access_token WHERE user_id = ?", userId)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]PersonalAccessToken, 0, request.PageSize)
+	query := fmt.Sprintf("SELECT * FROM personal_access_token WHERE user_id = ? ORDER BY %s %s LIMIT ? OFFSET ?", request.Field, request.SortBy)
+	err = repository.Select(&tokens, query, userId, request.PageSize, request.Offset())
+	if err != nil {
+		return nil, err
+	}
+
+	return paging.NewPage(tokens, request, total), nil
+}
+
+func (repository RepositoryImpl) isUserActive(userId int) (bool, error) {
+	var isActive bool
+	err := repository.Get(&isActive, "SELECT is_active FROM user WHERE id = ?", userId)
+	if err != nil {
+		return false, err
+	}
+
+	return isActive, nil
+}
+
+// updateLastUsed is only written once a minute so busy scripts do not write on every request
+func (repository RepositoryImpl) updateLastUsed(id int) error {
+	_, err := repository.NamedExec("UPDATE personal_access_token SET last_used_at = NOW() WHERE id = :id AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL 1 MINUTE)", map[string]any{
+		"id": id,
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository RepositoryImpl) revoke(userId, id int) (affectedRows int64, err error) {
+	result, err := repository.NamedExec("UPDATE personal_access_token SET revoked_at = NOW() WHERE id = :id AND user_id = :userId AND revoked_at IS NULL", map[string]any{
+		"id":     id,
+		"userId": userId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
+func (repository RepositoryImpl) revokeAllBy(userId int) (affectedRows int64, err error) {
+	result, err := repository.NamedExec("UPDATE personal_access_token SET revoked_at = NOW() WHERE user_id = :userId AND revoked_at IS NULL", map[string]any{
+		"userId": userId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
@@ -0,0 +1,162 @@
+package personal_access_token
+
+import (
+	"errors"
+	"log"
+	"slices"
+	"social-media-application/internal/paging"
+	"social-media-application/utils"
+	"strings"
+	"time"
+)
+
+const maxExpirationInDays = 365
+
+type (
+	Service interface {
+		save(userId int, name string, scopes []string, expiresInDays int) (id int64, token string, err error)
+
+		getAllBy(userId int, request *paging.PageRequest) (*paging.Page[PersonalAccessToken], error)
+
+		revoke(userId, id int) (affectedRows int64, err error)
+		RevokeAllBy(userId int) (affectedRows int64, err error) // used when the password is changed or reset
+
+		Authenticate(token string) (userId int, scopes []string, err error) // used by the Scoped middleware
+	}
+
+	ServiceImpl struct {
+		repository Repository
+	}
+)
+
+func NewService(repository Repository) Service {
+	return &ServiceImpl{
+		repository: repository,
+	}
+}
+
+func (s ServiceImpl) save(userId int, name string, scopes []string, expiresInDays int) (id int64, token string, err error) {
+	if userId <= 0 {
+		return 0, "", errors.New("user id is required")
+	}
+
+	if strings.TrimSpace(name) == "" {
+		return 0, "", errors.New("name is required")
+	}
+
+	if len(scopes) == 0 {
+		return 0, "", errors.New("at least one scope is required")
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(AllScopes, scope) {
+			return 0, "", errors.New("unknown scope " + scope)
+		}
+	}
+
+	if expiresInDays <= 0 || expiresInDays > maxExpirationInDays {
+		return 0, "", errors.New("expiration should be between 1 and 365 days")
+	}
+
+	random, err := utils.GenerateToken(32)
+	if err != nil {
+		return 0, "", err
+	}
+
+	token = Prefix + random
+	prefix := token[:len(Prefix)+6]
+	expiresAt := time.Now().AddDate(0, 0, expiresInDays)
+
+	slices.Sort(scopes)
+	id, err = s.repository.save(userId, strings.TrimSpace(name), prefix, utils.HashToken(token), slices.Compact(scopes), expiresAt)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return id, token, nil
+}
+
+func (s ServiceImpl) getAllBy(userId int, request *paging.PageRequest) (*paging.Page[PersonalAccessToken], error) {
+	if userId <= 0 {
+		return nil, errors.New("user id is required")
+	}
+
+	tokens, err := s.repository.findAllBy(userId, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (s ServiceImpl) revoke(userId, id int) (affectedRows int64, err error) {
+	if userId <= 0 {
+		return 0, errors.New("user id is required")
+	}
+
+	if id <= 0 {
+		return 0, errors.New("id is required")
+	}
+
+	affectedRows, err = s.repository.revoke(userId, id)
+	if err != nil {
+		return 0, err
+	}
+
+	if affectedRows <= 0 {
+		return 0, errors.New("no rows affected")
+	}
+
+	return affectedRows, nil
+}
+
+func (s ServiceImpl) RevokeAllBy(userId int) (affectedRows int64, err error) {
+	if userId <= 0 {
+		return 0, errors.New("user id is required")
+	}
+
+	// No affected rows only means the user has no active personal access token
+	affectedRows, err = s.repository.revokeAllBy(userId)
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
+// Authenticate also checks the user since a personal access token outlives the login that created it
+func (s ServiceImpl) Authenticate(token string) (userId int, scopes []string, err error) {
+	if !strings.HasPrefix(token, Prefix) {
+		return 0, nil, errors.New("invalid personal access token")
+	}
+
+	pat, err := s.repository.findBy(utils.HashToken(token))
+	if err != nil {
+		return 0, nil, errors.New("invalid personal access token")
+	}
+
+	if pat.IsRevoked() {
+		return 0, nil, errors.New("personal access token is revoked")
+	}
+
+	if pat.IsExpired() {
+		return 0, nil, errors.New("personal access token is expired")
+	}
+
+	isActive, err := s.repository.isUserActive(pat.UserId)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if !isActive {
+		return 0, nil, errors.New("user is inactive")
+	}
+
+	// Failing to track the last use should not block the request
+	err = s.repository.updateLastUsed(pat.Id)
+	if err != nil {
+		log.Println("WARNING: failed to update personal access token last used", err)
+	}
+
+	return pat.UserId, pat.Scopes, nil
+}
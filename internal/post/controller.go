@@ -15,9 +15,11 @@ type (
 		getById(ctx *gin.Context)
 		getAll(ctx *gin.Context)
 		getAllBy(ctx *gin.Context)
+		getTimeline(ctx *gin.Context)
 
 		updateContent(ctx *gin.Context)
 		updateAttachment(ctx *gin.Context)
+		updateVisibility(ctx *gin.Context)
 
 		deleteById(ctx *gin.Context)
 
@@ -36,16 +38,18 @@ func NewController(service Service) Controller {
 }
 
 func (c ControllerImpl) RegisterRoutes(e *gin.Engine) {
-	r := e.Group("/users/posts", middleware.JWT)
+	r := e.Group("/users/posts", middleware.Scoped("posts"))
 	{
 		r.POST("", c.save)
 
 		r.GET("/:id", c.getById)
 		r.GET("", c.getAll)
 		r.GET("/all-by-user", c.getAllBy)
+		r.GET("/timeline", c.getTimeline)
 
 		r.PATCH("/:id/content", c.updateContent)
 		r.PATCH("/:id/attachment", c.updateAttachment)
+		r.PATCH("/:id/visibility", c.updateVisibility)
 
 		r.DELETE("/:id", c.deleteById)
 	}
@@ -55,6 +59,8 @@ func (c ControllerImpl) save(ctx *gin.Context) {
 	request := struct {
 		Content    string `json:"content" binding:"required"`
 		Attachment string `json:"attachment"`
+		Visibility string `json:"visibility"`
+		Audience   []int  `json:"audience"`
 	}{}
 
 	if err := ctx.ShouldBind(&request); err != nil {
@@ -72,7 +78,11 @@ func (c ControllerImpl) save(ctx *gin.Context) {
 		return
 	}
 
-	id, err := c.service.save(sub, request.Content, request.Attachment)
+	if request.Visibility == "" {
+		request.Visibility = PUBLIC
+	}
+
+	id, err := c.service.save(sub, request.Content, request.Attachment, request.Visibility, request.Audience)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "save failed " + err.Error(),
@@ -84,6 +94,14 @@ func (c ControllerImpl) save(ctx *gin.Context) {
 }
 
 func (c ControllerImpl) getById(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get failed " + err.Error(),
+		})
+		return
+	}
+
 	postId, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
@@ -92,7 +110,15 @@ func (c ControllerImpl) getById(ctx *gin.Context) {
 		return
 	}
 
-	post, err := c.service.getById(postId)
+	includeSummary, err := strconv.ParseBool(ctx.DefaultQuery("includeSummary", "false"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get failed " + err.Error(),
+		})
+		return
+	}
+
+	post, err := c.service.GetById(sub, postId)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "get failed " + err.Error(),
@@ -100,6 +126,16 @@ func (c ControllerImpl) getById(ctx *gin.Context) {
 		return
 	}
 
+	if includeSummary {
+		post.Summary, err = c.service.getSummary(sub, post.Id)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"message": "get failed " + err.Error(),
+			})
+			return
+		}
+	}
+
 	ctx.JSON(http.StatusOK, post)
 }
 
@@ -120,6 +156,14 @@ func (c ControllerImpl) getAll(ctx *gin.Context) {
 		return
 	}
 
+	includeSummary, err := strconv.ParseBool(ctx.DefaultQuery("includeSummary", "false"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all failed " + err.Error(),
+		})
+		return
+	}
+
 	page := ctx.DefaultQuery("page", "1")
 	pageSize := ctx.DefaultQuery("pageSize", "10")
 	field := ctx.DefaultQuery("field", "created_at")
@@ -132,7 +176,7 @@ func (c ControllerImpl) getAll(ctx *gin.Context) {
 		return
 	}
 
-	posts, err := c.service.getAll(sub, isDeleted, request)
+	posts, err := c.service.getAll(sub, isDeleted, includeSummary, request)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "get all failed " + err.Error(),
@@ -160,6 +204,22 @@ func (c ControllerImpl) getAllBy(ctx *gin.Context) {
 		return
 	}
 
+	authorId, err := strconv.Atoi(ctx.DefaultQuery("authorId", strconv.Itoa(sub)))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all by failed " + err.Error(),
+		})
+		return
+	}
+
+	includeSummary, err := strconv.ParseBool(ctx.DefaultQuery("includeSummary", "false"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all by failed " + err.Error(),
+		})
+		return
+	}
+
 	page := ctx.DefaultQuery("page", "1")
 	pageSize := ctx.DefaultQuery("pageSize", "10")
 	field := ctx.DefaultQuery("field", "created_at")
@@ -172,7 +232,7 @@ func (c ControllerImpl) getAllBy(ctx *gin.Context) {
 		return
 	}
 
-	posts, err := c.service.getAllBy(sub, isDeleted, request)
+	posts, err := c.service.getAllBy(sub, authorId, isDeleted, includeSummary, request)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "get all by failed " + err.Error(),
@@ -183,6 +243,44 @@ func (c ControllerImpl) getAllBy(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, posts)
 }
 
+func (c ControllerImpl) getTimeline(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get timeline failed " + err.Error(),
+		})
+		return
+	}
+
+	includeSummary, err := strconv.ParseBool(ctx.DefaultQuery("includeSummary", "false"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get timeline failed " + err.Error(),
+		})
+		return
+	}
+
+	cursor := ctx.Query("cursor")
+	pageSize := ctx.DefaultQuery("pageSize", "10")
+	request, err := paging.NewCursorRequestStr(cursor, pageSize)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get timeline failed " + err.Error(),
+		})
+		return
+	}
+
+	posts, err := c.service.getTimeline(sub, includeSummary, request)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "get timeline failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, posts)
+}
+
 func (c ControllerImpl) updateContent(ctx *gin.Context) {
 	sub, err := middleware.GetSubject(ctx)
 	if err != nil {
@@ -241,6 +339,46 @@ func (c ControllerImpl) updateAttachment(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, attachment)
 }
 
+func (c ControllerImpl) updateVisibility(ctx *gin.Context) {
+	request := struct {
+		Visibility string `json:"visibility" binding:"required"`
+		Audience   []int  `json:"audience"`
+	}{}
+
+	if err := ctx.ShouldBind(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "update visibility failed " + err.Error(),
+		})
+		return
+	}
+
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "update visibility failed " + err.Error(),
+		})
+		return
+	}
+
+	postId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "update visibility failed " + err.Error(),
+		})
+		return
+	}
+
+	_, err = c.service.updateVisibility(sub, postId, request.Visibility, request.Audience)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "update visibility failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, request.Visibility)
+}
+
 func (c ControllerImpl) deleteById(ctx *gin.Context) {
 	sub, err := middleware.GetSubject(ctx)
 	if err != nil {
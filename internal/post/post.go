@@ -5,6 +5,14 @@ import (
 	"time"
 )
 
+const (
+	PUBLIC    = "PUBLIC"
+	FOLLOWERS = "FOLLOWERS"
+	FRIENDS   = "FRIENDS"
+	ONLY_ME   = "ONLY_ME"
+	CUSTOM    = "CUSTOM" // only the users in post_audience
+)
+
 type Post struct {
 	Id         int            `json:"id" db:"id"`
 	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
@@ -12,4 +20,14 @@ type Post struct {
 	Attachment sql.NullString `json:"attachment" db:"attachment"`
 	IsDeleted  bool           `json:"-" db:"is_deleted"`
 	AuthorId   int            `json:"author_id" db:"author_id"`
+	Visibility string         `json:"visibility" db:"visibility"`
+	Summary    *Summary       `json:"summary,omitempty" db:"-"`
+}
+
+// Summary is only filled when requested with includeSummary
+type Summary struct {
+	TotalReactions int         `json:"total_reactions"`
+	Reactions      map[int]int `json:"reactions"` // emoji id to count
+	CommentCount   int         `json:"comment_count"`
+	MyReaction     *int        `json:"my_reaction"` // emoji id of the current user's reaction
 }
@@ -1,6 +1,7 @@
 package reaction
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"social-media-application/internal/paging"
@@ -35,7 +36,7 @@ func NewController(service Service) Controller {
 }
 
 func (c ControllerImpl) RegisterRoutes(e *gin.Engine) {
-	r := e.Group("/users/posts/:id/reactions", middleware.JWT)
+	r := e.Group("/users/posts/:id/reactions", middleware.Scoped("reactions"))
 	{
 		r.POST("", c.save)
 
@@ -75,6 +76,13 @@ func (c ControllerImpl) save(ctx *gin.Context) {
 
 	id, err := c.service.save(sub, postId, emojiId)
 	if err != nil {
+		if errors.Is(err, ErrAlreadyReacted) {
+			ctx.JSON(http.StatusConflict, gin.H{
+				"message": "saved failed " + err.Error(),
+			})
+			return
+		}
+
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "saved failed " + err.Error(),
 		})
@@ -85,6 +93,14 @@ func (c ControllerImpl) save(ctx *gin.Context) {
 }
 
 func (c ControllerImpl) getById(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get by id failed " + err.Error(),
+		})
+		return
+	}
+
 	postId, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
@@ -101,7 +117,7 @@ func (c ControllerImpl) getById(ctx *gin.Context) {
 		return
 	}
 
-	reaction, err := c.service.getById(postId, reactionId)
+	reaction, err := c.service.getById(sub, postId, reactionId)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "get by id failed " + err.Error(),
@@ -113,6 +129,14 @@ func (c ControllerImpl) getById(ctx *gin.Context) {
 }
 
 func (c ControllerImpl) getAll(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all failed " + err.Error(),
+		})
+		return
+	}
+
 	postId, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
@@ -133,7 +157,7 @@ func (c ControllerImpl) getAll(ctx *gin.Context) {
 		return
 	}
 
-	reactions, err := c.service.getAll(postId, request)
+	reactions, err := c.service.getAll(sub, postId, request)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "get all failed " + err.Error(),
@@ -145,6 +169,14 @@ func (c ControllerImpl) getAll(ctx *gin.Context) {
 }
 
 func (c ControllerImpl) getAllByEmoji(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all by emoji failed " + err.Error(),
+		})
+		return
+	}
+
 	postId, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
@@ -173,7 +205,7 @@ func (c ControllerImpl) getAllByEmoji(ctx *gin.Context) {
 		return
 	}
 
-	reactions, err := c.service.getAllByEmoji(postId, emojiId, request)
+	reactions, err := c.service.getAllByEmoji(sub, postId, emojiId, request)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "get all by emoji failed " + err.Error(),
@@ -1,9 +1,12 @@
 package reaction
 
 import (
+	"errors"
 	"time"
 )
 
+var ErrAlreadyReacted = errors.New("reactor already reacted")
+
 type Reaction struct {
 	Id        int       `json:"id" db:"id"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
@@ -3,15 +3,17 @@ package reaction
 import (
 	"errors"
 	"social-media-application/internal/paging"
+	"social-media-application/internal/post"
+	"social-media-application/utils"
 )
 
 type (
 	Service interface {
 		save(reactorId, postId, emojiId int) (id int64, err error)
 
-		getById(postId, reactionId int) (Reaction, error)
-		getAll(postId int, request *paging.PageRequest) (*paging.Page[Reaction], error)
-		getAllByEmoji(postId int, emojiId int, request *paging.PageRequest) (*paging.Page[Reaction], error)
+		getById(currentUserId, postId, reactionId int) (Reaction, error)
+		getAll(currentUserId, postId int, request *paging.PageRequest) (*paging.Page[Reaction], error)
+		getAllByEmoji(currentUserId, postId int, emojiId int, request *paging.PageRequest) (*paging.Page[Reaction], error)
 
 		update(reactorId, postId, newEmojiId int) (affectedRows int64, err error)
 
@@ -19,13 +21,15 @@ type (
 	}
 
 	ServiceImpl struct {
-		repository Repository
+		repository  Repository
+		postService post.Service
 	}
 )
 
-func NewService(repository Repository) Service {
+func NewService(repository Repository, postService post.Service) Service {
 	return &ServiceImpl{
-		repository: repository,
+		repository:  repository,
+		postService: postService,
 	}
 }
 
@@ -42,28 +46,43 @@ func (s ServiceImpl) save(reactorId, postId, emojiId int) (id int64, err error)
 		return 0, errors.New("emoji id is required")
 	}
 
+	_, err = s.postService.GetById(reactorId, postId)
+	if err != nil {
+		return 0, err
+	}
+
 	isAlreadyReacted, err := s.repository.isAlreadyReacted(reactorId, postId)
 	if err != nil {
 		return 0, err
 	}
 
 	if isAlreadyReacted {
-		return 0, errors.New("reactor already reacted")
+		return 0, ErrAlreadyReacted
 	}
 
+	// Concurrent reactions can both pass the check above, the unique key rejects the later one
 	id, err = s.repository.save(reactorId, postId, emojiId)
 	if err != nil {
+		if utils.IsDuplicateEntry(err) {
+			return 0, ErrAlreadyReacted
+		}
+
 		return 0, err
 	}
 
 	return id, nil
 }
 
-func (s ServiceImpl) getById(postId, reactionId int) (Reaction, error) {
+func (s ServiceImpl) getById(currentUserId, postId, reactionId int) (Reaction, error) {
 	if reactionId <= 0 {
 		return Reaction{}, errors.New("post id is required")
 	}
 
+	_, err := s.postService.GetById(currentUserId, postId)
+	if err != nil {
+		return Reaction{}, err
+	}
+
 	reaction, err := s.repository.findById(postId, reactionId)
 	if err != nil {
 		return Reaction{}, err
@@ -72,11 +91,16 @@ func (s ServiceImpl) getById(postId, reactionId int) (Reaction, error) {
 	return reaction, nil
 }
 
-func (s ServiceImpl) getAll(postId int, request *paging.PageRequest) (*paging.Page[Reaction], error) {
+func (s ServiceImpl) getAll(currentUserId, postId int, request *paging.PageRequest) (*paging.Page[Reaction], error) {
 	if postId <= 0 {
 		return nil, errors.New("post id is required")
 	}
 
+	_, err := s.postService.GetById(currentUserId, postId)
+	if err != nil {
+		return nil, err
+	}
+
 	reactions, err := s.repository.findAll(postId, request)
 	if err != nil {
 		return nil, err
@@ -85,7 +109,7 @@ func (s ServiceImpl) getAll(postId int, request *paging.PageRequest) (*paging.Pa
 	return reactions, nil
 }
 
-func (s ServiceImpl) getAllByEmoji(postId int, emojiId int, request *paging.PageRequest) (*paging.Page[Reaction], error) {
+func (s ServiceImpl) getAllByEmoji(currentUserId, postId int, emojiId int, request *paging.PageRequest) (*paging.Page[Reaction], error) {
 	if postId <= 0 {
 		return nil, errors.New("post id is required")
 	}
@@ -94,6 +118,11 @@ func (s ServiceImpl) getAllByEmoji(postId int, emojiId int, request *paging.Page
 		return nil, errors.New("emoji id is required")
 	}
 
+	_, err := s.postService.GetById(currentUserId, postId)
+	if err != nil {
+		return nil, err
+	}
+
 	reactions, err := s.repository.findAllByEmoji(postId, emojiId, request)
 	if err != nil {
 		return nil, err
@@ -115,6 +144,11 @@ func (s ServiceImpl) update(reactorId, postId, newEmojiId int) (affectedRows int
 		return 0, errors.New("new emoji id is required")
 	}
 
+	_, err = s.postService.GetById(reactorId, postId)
+	if err != nil {
+		return 0, err
+	}
+
 	isAlreadyReacted, err := s.repository.isAlreadyReacted(reactorId, postId)
 	if err != nil {
 		return 0, err
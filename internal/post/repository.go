@@ -8,21 +8,54 @@ import (
 	"social-media-application/utils"
 )
 
+// excludeBlockedAndMuted hides authors the current user blocked, was blocked by, or muted
+// Expects the current user id three times
+const excludeBlockedAndMuted = `
+	AND p.author_id NOT IN (SELECT blocked_id FROM block WHERE blocker_id = ?)
+	AND p.author_id NOT IN (SELECT blocker_id FROM block WHERE blocked_id = ?)
+	AND p.author_id NOT IN (SELECT muted_id FROM mute WHERE muter_id = ?)
+`
+
+// visibleTo keeps only the posts the current user is in the audience of
+// Expects the current user id five times
+const visibleTo = `
+	AND (
+		p.author_id = ?
+		OR p.visibility = 'PUBLIC'
+		OR (p.visibility = 'FOLLOWERS' AND EXISTS (SELECT 1 FROM follow f WHERE f.follower_id = ? AND f.followee_id = p.author_id))
+		OR (p.visibility = 'FRIENDS' AND EXISTS (
+			SELECT 1 FROM friendship fr
+			WHERE fr.status = 'ACCEPTED'
+			AND ((fr.requester_id = ? AND fr.addressee_id = p.author_id) OR (fr.requester_id = p.author_id AND fr.addressee_id = ?))
+		))
+		OR (p.visibility = 'CUSTOM' AND EXISTS (SELECT 1 FROM post_audience pa WHERE pa.post_id = p.id AND pa.user_id = ?))
+	)
+`
+
 type (
 	Repository interface {
-		save(authorId int, content, attachment string) (id int64, err error)
+		save(authorId int, content, attachment, visibility string, audience []int) (id int64, err error)
 
 		findById(postId int) (Post, error)
 		findAll(currentUserId int, isDeleted bool, request *paging.PageRequest) (*paging.Page[Post], error)
 
-		findAllBy(currentUserId int, isDeleted bool, request *paging.PageRequest) (*paging.Page[Post], error)
+		findAllBy(currentUserId, authorId int, isDeleted bool, request *paging.PageRequest) (*paging.Page[Post], error)
+
+		findTimeline(currentUserId int, request *paging.CursorRequest) (*paging.CursorPage[Post], error)
+		findCachedTimeline(currentUserId int, request *paging.CursorRequest) (*paging.CursorPage[Post], error)
+
+		fanOut(authorId int, postId int64) (affectedRows int64, err error)
 
 		updateContent(currentUserId, postId int, newContent string) (affectedRows int64, err error)
 		updateAttachment(currentUserId, postId int, newAttachment string) (affectedRows int64, err error)
+		updateVisibility(currentUserId, postId int, visibility string, audience []int) (affectedRows int64, err error)
 
 		deleteById(currentUserId, postId int) (affectedRows int64, err error)
 
 		hasPost(currentUserId, postId int) (exists bool, err error)
+		isVisible(currentUserId, postId int) (bool, error)
+
+		findSummaries(currentUserId int, postIds []int) (map[int]*Summary, error)
 	}
 
 	RepositoryImpl struct {
@@ -36,11 +69,23 @@ func NewRepository(db *sqlx.DB) Repository {
 	}
 }
 
-func (repository RepositoryImpl) save(authorId int, content, attachment string) (id int64, err error) {
-	result, err := repository.NamedExec("INSERT INTO post (content, attachment, author_id) VALUES (:content, :attachment, :authorId)", map[string]any{
+func (repository RepositoryImpl) save(authorId int, content, attachment, visibility string, audience []int) (id int64, err error) {
+	tx, err := repository.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer func(tx *sqlx.Tx) {
+		err := tx.Rollback()
+		if err != nil {
+			return
+		}
+	}(tx)
+
+	result, err := tx.NamedExec("INSERT INTO post (content, attachment, author_id, visibility) VALUES (:content, :attachment, :authorId, :visibility)", map[string]any{
 		"content":    content,
 		"attachment": attachment,
 		"authorId":   authorId,
+		"visibility": visibility,
 	})
 
 	if err != nil {
@@ -52,6 +97,16 @@ func (repository RepositoryImpl) save(authorId int, content, attachment string)
 		return 0, err
 	}
 
+	err = saveAudience(tx, id, audience)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
 	return id, nil
 }
 
@@ -77,14 +132,17 @@ func (repository RepositoryImpl) findAll(currentUserId int, isDeleted bool, requ
 	}
 
 	var total int
-	err := repository.Get(&total, "SELECT COUNT(*) FROM post WHERE author_id != ? AND is_deleted = ?", currentUserId, isDeleted)
+	err := repository.Get(&total, "SELECT COUNT(*) FROM post p WHERE p.author_id != ? AND p.is_deleted = ?"+excludeBlockedAndMuted+visibleTo,
+		currentUserId, isDeleted, currentUserId, currentUserId, currentUserId, currentUserId, currentUserId, currentUserId, currentUserId, currentUserId)
 	if err != nil {
 		return nil, err
 	}
 
-	posts := make([]Post, request.PageSize)
-	query := fmt.Sprintf("SELECT * FROM post WHERE author_id != ? AND is_deleted = ? ORDER BY %s %s LIMIT ? OFFSET ?", request.Field, request.SortBy)
-	err = repository.Select(&posts, query, currentUserId, isDeleted, request.PageSize, request.Offset())
+	posts := make([]Post, 0, request.PageSize)
+	query := fmt.Sprintf("SELECT p.* FROM post p WHERE p.author_id != ? AND p.is_deleted = ? %s %s ORDER BY %s %s LIMIT ? OFFSET ?", excludeBlockedAndMuted, visibleTo, request.Field, request.SortBy)
+	err = repository.Select(&posts, query,
+		currentUserId, isDeleted, currentUserId, currentUserId, currentUserId, currentUserId, currentUserId, currentUserId, currentUserId, currentUserId,
+		request.PageSize, request.Offset())
 	if err != nil {
 		return nil, err
 	}
@@ -92,7 +150,7 @@ func (repository RepositoryImpl) findAll(currentUserId int, isDeleted bool, requ
 	return paging.NewPage(posts, request, total), nil
 }
 
-func (repository RepositoryImpl) findAllBy(currentUserId int, isDeleted bool, request *paging.PageRequest) (*paging.Page[Post], error) {
+func (repository RepositoryImpl) findAllBy(currentUserId, authorId int, isDeleted bool, request *paging.PageRequest) (*paging.Page[Post], error) {
 	if !utils.IsInDBTag(request.Field, Post{}) {
 		request.Field = "created_at"
 		log.Println("WARNING: field is not in database! defaulted to", request.Field)
@@ -104,14 +162,17 @@ func (repository RepositoryImpl) findAllBy(currentUserId int, isDeleted bool, re
 	}
 
 	var total int
-	err := repository.Get(&total, "SELECT COUNT(*) FROM post WHERE author_id = ? AND is_deleted = ?", currentUserId, isDeleted)
+	err := repository.Get(&total, "SELECT COUNT(*) FROM post p WHERE p.author_id = ? AND p.is_deleted = ?"+visibleTo,
+		authorId, isDeleted, currentUserId, currentUserId, currentUserId, currentUserId, currentUserId)
 	if err != nil {
 		return nil, err
 	}
 
-	posts := make([]Post, request.PageSize)
-	query := fmt.Sprintf("SELECT * FROM post WHERE author_id = ? AND is_deleted = ? ORDER BY %s %s LIMIT ? OFFSET ?", request.Field, request.SortBy)
-	err = repository.Select(&posts, query, currentUserId, isDeleted, request.PageSize, request.Offset())
+	posts := make([]Post, 0, request.PageSize)
+	query := fmt.Sprintf("SELECT p.* FROM post p WHERE p.author_id = ? AND p.is_deleted = ? %s ORDER BY %s %s LIMIT ? OFFSET ?", visibleTo, request.Field, request.SortBy)
+	err = repository.Select(&posts, query,
+		authorId, isDeleted, currentUserId, currentUserId, currentUserId, currentUserId, currentUserId,
+		request.PageSize, request.Offset())
 	if err != nil {
 		return nil, err
 	}
@@ -119,6 +180,97 @@ func (repository RepositoryImpl) findAllBy(currentUserId int, isDeleted bool, re
 	return paging.NewPage(posts, request, total), nil
 }
 
+func (repository RepositoryImpl) findTimeline(currentUserId int, request *paging.CursorRequest) (*paging.CursorPage[Post], error) {
+	query := `
+		SELECT p.*
+		FROM post p
+		WHERE p.is_deleted = false
+		AND (
+			p.author_id = ?
+			OR p.author_id IN (SELECT f.followee_id FROM follow f WHERE f.follower_id = ?)
+		)
+	` + excludeBlockedAndMuted + visibleTo
+	args := []any{currentUserId, currentUserId, currentUserId, currentUserId, currentUserId, currentUserId, currentUserId, currentUserId, currentUserId, currentUserId}
+
+	if request.Cursor != nil {
+		query += " AND (p.created_at < ? OR (p.created_at = ? AND p.id < ?))"
+		args = append(args, request.Cursor.CreatedAt, request.Cursor.CreatedAt, request.Cursor.Id)
+	}
+
+	// Fetch one extra row to know if there's a next page
+	query += " ORDER BY p.created_at DESC, p.id DESC LIMIT ?"
+	args = append(args, request.PageSize+1)
+
+	posts := make([]Post, 0, request.PageSize+1)
+	err := repository.Select(&posts, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return paging.NewCursorPage(posts, request, cursorOf), nil
+}
+
+func (repository RepositoryImpl) findCachedTimeline(currentUserId int, request *paging.CursorRequest) (*paging.CursorPage[Post], error) {
+	// The follow check filters out authors that were unfollowed after their posts were fanned out
+	query := `
+		SELECT p.*
+		FROM timeline t
+		JOIN post p ON p.id = t.post_id
+		WHERE t.user_id = ?
+		AND p.is_deleted = false
+		AND (
+			p.author_id = ?
+			OR EXISTS (SELECT 1 FROM follow f WHERE f.follower_id = ? AND f.followee_id = p.author_id)
+		)
+	` + excludeBlockedAndMuted + visibleTo
+	args := []any{currentUserId, currentUserId, currentUserId, currentUserId, currentUserId, currentUserId, currentUserId, currentUserId, currentUserId, currentUserId, currentUserId}
+
+	if request.Cursor != nil {
+		query += " AND (t.created_at < ? OR (t.created_at = ? AND t.post_id < ?))"
+		args = append(args, request.Cursor.CreatedAt, request.Cursor.CreatedAt, request.Cursor.Id)
+	}
+
+	// Fetch one extra row to know if there's a next page
+	query += " ORDER BY t.created_at DESC, t.post_id DESC LIMIT ?"
+	args = append(args, request.PageSize+1)
+
+	posts := make([]Post, 0, request.PageSize+1)
+	err := repository.Select(&posts, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return paging.NewCursorPage(posts, request, cursorOf), nil
+}
+
+func (repository RepositoryImpl) fanOut(authorId int, postId int64) (affectedRows int64, err error) {
+	query := `
+		INSERT INTO timeline (user_id, post_id, created_at)
+		SELECT f.follower_id, p.id, p.created_at
+		FROM follow f
+		JOIN post p ON p.id = :postId
+		WHERE f.followee_id = :authorId
+		UNION ALL
+		SELECT p.author_id, p.id, p.created_at
+		FROM post p
+		WHERE p.id = :postId
+	`
+	result, err := repository.NamedExec(query, map[string]any{
+		"authorId": authorId,
+		"postId":   postId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
 func (repository RepositoryImpl) updateContent(currentUserId, postId int, newContent string) (affectedRows int64, err error) {
 	result, err := repository.NamedExec("UPDATE post SET content = :content WHERE id = :id AND author_id = :authorId", map[string]any{
 		"content":  newContent,
@@ -156,6 +308,51 @@ func (repository RepositoryImpl) updateAttachment(currentUserId, postId int, new
 	return affectedRows, nil
 }
 
+func (repository RepositoryImpl) updateVisibility(currentUserId, postId int, visibility string, audience []int) (affectedRows int64, err error) {
+	tx, err := repository.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer func(tx *sqlx.Tx) {
+		err := tx.Rollback()
+		if err != nil {
+			return
+		}
+	}(tx)
+
+	result, err := tx.NamedExec("UPDATE post SET visibility = :visibility WHERE id = :postId AND author_id = :authorId", map[string]any{
+		"visibility": visibility,
+		"postId":     postId,
+		"authorId":   currentUserId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	// Old audience is always replaced, it only matters for CUSTOM posts
+	_, err = tx.Exec("DELETE FROM post_audience WHERE post_id = ?", postId)
+	if err != nil {
+		return 0, err
+	}
+
+	err = saveAudience(tx, int64(postId), audience)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
 func (repository RepositoryImpl) deleteById(currentUserId, postId int) (affectedRows int64, err error) {
 	result, err := repository.NamedExec("UPDATE post SET is_deleted = true WHERE id = :postId AND author_id = :currentUserId", map[string]any{
 		"postId":        postId,
@@ -182,3 +379,105 @@ func (repository RepositoryImpl) hasPost(currentUserId, postId int) (exists bool
 
 	return exists, nil
 }
+
+func (repository RepositoryImpl) isVisible(currentUserId, postId int) (bool, error) {
+	var exists bool
+	query := "SELECT EXISTS(SELECT 1 FROM post p WHERE p.id = ?" + visibleTo + ")"
+	err := repository.Get(&exists, query, postId, currentUserId, currentUserId, currentUserId, currentUserId, currentUserId)
+	if err != nil {
+		return exists, err
+	}
+
+	return exists, nil
+}
+
+// findSummaries counts reactions and comments of the whole page with grouped queries instead of one query per post
+func (repository RepositoryImpl) findSummaries(currentUserId int, postIds []int) (map[int]*Summary, error) {
+	summaries := make(map[int]*Summary, len(postIds))
+	for _, id := range postIds {
+		summaries[id] = &Summary{
+			Reactions: make(map[int]int),
+		}
+	}
+
+	if len(postIds) == 0 {
+		return summaries, nil
+	}
+
+	reactionCounts := make([]struct {
+		Id      int `db:"id"`
+		EmojiId int `db:"emoji_id"`
+		Total   int `db:"total"`
+	}, 0, len(postIds))
+	query, args, err := sqlx.In("SELECT post_id AS id, emoji_id, COUNT(*) AS total FROM post_reaction WHERE post_id IN (?) GROUP BY post_id, emoji_id", postIds)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repository.Select(&reactionCounts, repository.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, count := range reactionCounts {
+		summaries[count.Id].Reactions[count.EmojiId] = count.Total
+		summaries[count.Id].TotalReactions += count.Total
+	}
+
+	commentCounts := make([]struct {
+		Id    int `db:"id"`
+		Total int `db:"total"`
+	}, 0, len(postIds))
+	query, args, err = sqlx.In("SELECT post_id AS id, COUNT(*) AS total FROM comment WHERE post_id IN (?) AND is_deleted = false GROUP BY post_id", postIds)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repository.Select(&commentCounts, repository.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, count := range commentCounts {
+		summaries[count.Id].CommentCount = count.Total
+	}
+
+	myReactions := make([]struct {
+		Id      int `db:"id"`
+		EmojiId int `db:"emoji_id"`
+	}, 0, len(postIds))
+	query, args, err = sqlx.In("SELECT post_id AS id, emoji_id FROM post_reaction WHERE reactor_id = ? AND post_id IN (?)", currentUserId, postIds)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repository.Select(&myReactions, repository.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, reaction := range myReactions {
+		emojiId := reaction.EmojiId
+		summaries[reaction.Id].MyReaction = &emojiId
+	}
+
+	return summaries, nil
+}
+
+func saveAudience(tx *sqlx.Tx, postId int64, audience []int) error {
+	for _, userId := range audience {
+		_, err := tx.Exec("INSERT INTO post_audience (post_id, user_id) VALUES (?, ?)", postId, userId)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func cursorOf(post Post) paging.Cursor {
+	return paging.Cursor{
+		CreatedAt: post.CreatedAt,
+		Id:        post.Id,
+	}
+}
@@ -2,36 +2,45 @@ package post
 
 import (
 	"errors"
+	"log"
+	"os"
+	"social-media-application/internal/block"
 	"social-media-application/internal/paging"
+	"strconv"
 	"strings"
 )
 
 type (
 	Service interface {
-		save(authorId int, content, attachment string) (id int64, err error)
+		save(authorId int, content, attachment, visibility string, audience []int) (id int64, err error)
 
-		getById(postId int) (Post, error)
-		getAll(currentUserId int, isDeleted bool, request *paging.PageRequest) (*paging.Page[Post], error)
-		getAllBy(currentUserId int, isDeleted bool, request *paging.PageRequest) (*paging.Page[Post], error)
+		GetById(currentUserId, postId int) (Post, error)
+		getAll(currentUserId int, isDeleted, includeSummary bool, request *paging.PageRequest) (*paging.Page[Post], error)
+		getAllBy(currentUserId, authorId int, isDeleted, includeSummary bool, request *paging.PageRequest) (*paging.Page[Post], error)
+		getTimeline(currentUserId int, includeSummary bool, request *paging.CursorRequest) (*paging.CursorPage[Post], error)
+		getSummary(currentUserId, postId int) (*Summary, error)
 
 		updateContent(currentUserId, postId int, newContent string) (affectedRows int64, err error)
 		updateAttachment(currentUserId, postId int, newAttachment string) (affectedRows int64, err error)
+		updateVisibility(currentUserId, postId int, visibility string, audience []int) (affectedRows int64, err error)
 
 		deleteById(currentUserId, postId int) (affectedRows int64, err error)
 	}
 
 	ServiceImpl struct {
-		repository Repository
+		repository   Repository
+		blockService block.Service
 	}
 )
 
-func NewService(repository Repository) Service {
+func NewService(repository Repository, blockService block.Service) Service {
 	return &ServiceImpl{
-		repository: repository,
+		repository:   repository,
+		blockService: blockService,
 	}
 }
 
-func (s ServiceImpl) save(authorId int, content, attachment string) (id int64, err error) {
+func (s ServiceImpl) save(authorId int, content, attachment, visibility string, audience []int) (id int64, err error) {
 	if authorId <= 0 {
 		return 0, errors.New("author id is required")
 	}
@@ -40,15 +49,35 @@ func (s ServiceImpl) save(authorId int, content, attachment string) (id int64, e
 		return 0, errors.New("content is required")
 	}
 
-	id, err = s.repository.save(authorId, content, attachment)
+	audience, err = validateVisibility(visibility, audience)
 	if err != nil {
 		return 0, err
 	}
 
+	id, err = s.repository.save(authorId, content, attachment, visibility, audience)
+	if err != nil {
+		return 0, err
+	}
+
+	// The post is already saved and the timeline falls back to the follow graph
+	// So a failed fan-out should not fail the request
+	if isFanOutOnWrite() {
+		_, err = s.repository.fanOut(authorId, id)
+		if err != nil {
+			log.Println("WARNING: fan-out on write failed for post", id, err)
+		}
+	}
+
 	return id, nil
 }
 
-func (s ServiceImpl) getById(postId int) (Post, error) {
+// GetById also guards comments and reactions
+// so blocked users and users outside the audience cannot read or interact with the post
+func (s ServiceImpl) GetById(currentUserId, postId int) (Post, error) {
+	if currentUserId <= 0 {
+		return Post{}, errors.New("current user id is required")
+	}
+
 	if postId <= 0 {
 		return Post{}, errors.New("post id is required")
 	}
@@ -58,10 +87,30 @@ func (s ServiceImpl) getById(postId int) (Post, error) {
 		return Post{}, err
 	}
 
+	if post.AuthorId != currentUserId {
+		isBlocked, err := s.blockService.IsBlocked(currentUserId, post.AuthorId)
+		if err != nil {
+			return Post{}, err
+		}
+
+		if isBlocked {
+			return Post{}, errors.New("post is not available")
+		}
+
+		isVisible, err := s.repository.isVisible(currentUserId, postId)
+		if err != nil {
+			return Post{}, err
+		}
+
+		if !isVisible {
+			return Post{}, errors.New("post is not available")
+		}
+	}
+
 	return post, nil
 }
 
-func (s ServiceImpl) getAll(currentUserId int, isDeleted bool, request *paging.PageRequest) (*paging.Page[Post], error) {
+func (s ServiceImpl) getAll(currentUserId int, isDeleted, includeSummary bool, request *paging.PageRequest) (*paging.Page[Post], error) {
 	if currentUserId <= 0 {
 		return nil, errors.New("author id is required")
 	}
@@ -71,22 +120,99 @@ func (s ServiceImpl) getAll(currentUserId int, isDeleted bool, request *paging.P
 		return nil, err
 	}
 
+	if includeSummary {
+		err = s.summarize(currentUserId, posts.Content)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return posts, nil
 }
 
-func (s ServiceImpl) getAllBy(currentUserId int, isDeleted bool, request *paging.PageRequest) (*paging.Page[Post], error) {
+func (s ServiceImpl) getAllBy(currentUserId, authorId int, isDeleted, includeSummary bool, request *paging.PageRequest) (*paging.Page[Post], error) {
 	if currentUserId <= 0 {
+		return nil, errors.New("current user id is required")
+	}
+
+	if authorId <= 0 {
 		return nil, errors.New("author id is required")
 	}
 
-	posts, err := s.repository.findAllBy(currentUserId, isDeleted, request)
+	// Only the author can see their own deleted posts
+	if currentUserId != authorId {
+		if isDeleted {
+			return nil, errors.New("cannot view deleted posts of other users")
+		}
+
+		isBlocked, err := s.blockService.IsBlocked(currentUserId, authorId)
+		if err != nil {
+			return nil, err
+		}
+
+		if isBlocked {
+			return nil, errors.New("posts are not available")
+		}
+	}
+
+	posts, err := s.repository.findAllBy(currentUserId, authorId, isDeleted, request)
 	if err != nil {
 		return nil, err
 	}
 
+	if includeSummary {
+		err = s.summarize(currentUserId, posts.Content)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return posts, nil
 }
 
+func (s ServiceImpl) getTimeline(currentUserId int, includeSummary bool, request *paging.CursorRequest) (*paging.CursorPage[Post], error) {
+	if currentUserId <= 0 {
+		return nil, errors.New("author id is required")
+	}
+
+	var posts *paging.CursorPage[Post]
+	var err error
+	if isFanOutOnWrite() {
+		posts, err = s.repository.findCachedTimeline(currentUserId, request)
+	} else {
+		posts, err = s.repository.findTimeline(currentUserId, request)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if includeSummary {
+		err = s.summarize(currentUserId, posts.Content)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return posts, nil
+}
+
+func (s ServiceImpl) getSummary(currentUserId, postId int) (*Summary, error) {
+	if currentUserId <= 0 {
+		return nil, errors.New("current user id is required")
+	}
+
+	if postId <= 0 {
+		return nil, errors.New("post id is required")
+	}
+
+	summaries, err := s.repository.findSummaries(currentUserId, []int{postId})
+	if err != nil {
+		return nil, err
+	}
+
+	return summaries[postId], nil
+}
+
 func (s ServiceImpl) updateContent(currentUserId, postId int, newContent string) (affectedRows int64, err error) {
 	if currentUserId <= 0 {
 		return 0, errors.New("author id is required")
@@ -137,6 +263,38 @@ func (s ServiceImpl) updateAttachment(currentUserId, postId int, newAttachment s
 	return affectedRows, nil
 }
 
+func (s ServiceImpl) updateVisibility(currentUserId, postId int, visibility string, audience []int) (affectedRows int64, err error) {
+	if currentUserId <= 0 {
+		return 0, errors.New("author id is required")
+	}
+
+	if postId <= 0 {
+		return 0, errors.New("post id is required")
+	}
+
+	audience, err = validateVisibility(visibility, audience)
+	if err != nil {
+		return 0, err
+	}
+
+	// Checked first because updating to the same visibility affects no rows
+	hasPost, err := s.repository.hasPost(currentUserId, postId)
+	if err != nil {
+		return 0, err
+	}
+
+	if !hasPost {
+		return 0, errors.New("current user is not the author of post")
+	}
+
+	affectedRows, err = s.repository.updateVisibility(currentUserId, postId, visibility, audience)
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
 func (s ServiceImpl) deleteById(currentUserId, postId int) (affectedRows int64, err error) {
 	if currentUserId <= 0 {
 		return 0, errors.New("author id is required")
@@ -157,3 +315,65 @@ func (s ServiceImpl) deleteById(currentUserId, postId int) (affectedRows int64,
 
 	return affectedRows, nil
 }
+
+// summarize fills the summary of every post in place
+func (s ServiceImpl) summarize(currentUserId int, posts []Post) error {
+	postIds := make([]int, 0, len(posts))
+	for _, post := range posts {
+		postIds = append(postIds, post.Id)
+	}
+
+	summaries, err := s.repository.findSummaries(currentUserId, postIds)
+	if err != nil {
+		return err
+	}
+
+	for i := range posts {
+		posts[i].Summary = summaries[posts[i].Id]
+	}
+
+	return nil
+}
+
+// validateVisibility returns the audience to be saved
+// Audience is only kept for CUSTOM posts
+func validateVisibility(visibility string, audience []int) ([]int, error) {
+	switch visibility {
+	case PUBLIC, FOLLOWERS, FRIENDS, ONLY_ME:
+		return nil, nil
+	case CUSTOM:
+		if len(audience) == 0 {
+			return nil, errors.New("audience is required for custom visibility")
+		}
+
+		unique := make([]int, 0, len(audience))
+		seen := make(map[int]bool, len(audience))
+		for _, userId := range audience {
+			if userId <= 0 {
+				return nil, errors.New("audience contains invalid user id")
+			}
+
+			if seen[userId] {
+				continue
+			}
+
+			seen[userId] = true
+			unique = append(unique, userId)
+		}
+
+		return unique, nil
+	default:
+		return nil, errors.New("visibility must be one of PUBLIC, FOLLOWERS, FRIENDS, ONLY_ME or CUSTOM")
+	}
+}
+
+// isFanOutOnWrite enables the materialized timeline table
+// Posts created before enabling this or before following an author will not appear in the cached timeline
+func isFanOutOnWrite() bool {
+	enabled, err := strconv.ParseBool(os.Getenv("TIMELINE_FAN_OUT_ON_WRITE"))
+	if err != nil {
+		return false
+	}
+
+	return enabled
+}
@@ -1,8 +1,12 @@
 package refresh
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"social-media-application/internal/audit"
+	"social-media-application/internal/paging"
+	"social-media-application/internal/role"
 	"social-media-application/middlewares"
 	"social-media-application/utils"
 	"strconv"
@@ -13,17 +17,24 @@ type (
 		refresh(ctx *gin.Context)
 		getAllBy(ctx *gin.Context)
 		revoke(ctx *gin.Context)
+
+		getAllSessions(ctx *gin.Context)
+		revokeSession(ctx *gin.Context)
+		revokeOtherSessions(ctx *gin.Context)
+
 		RegisterRoutes(c *gin.Engine)
 	}
 
 	ControllerImpl struct {
-		service Service
+		service     Service
+		roleService role.Service
 	}
 )
 
-func NewController(service Service) Controller {
+func NewController(service Service, roleService role.Service) Controller {
 	return &ControllerImpl{
-		service: service,
+		service:     service,
+		roleService: roleService,
 	}
 }
 
@@ -37,15 +48,20 @@ func (c *ControllerImpl) RegisterRoutes(e *gin.Engine) {
 		r.GET("", middleware.JWT, c.getAllBy)
 		r.DELETE("/:id", middleware.JWT, c.revoke)
 	}
+
+	sessions := e.Group("/users/sessions", middleware.JWT)
+	{
+		sessions.GET("", c.getAllSessions)
+		sessions.DELETE("/:id", c.revokeSession)
+		sessions.POST("/logout-others", c.revokeOtherSessions)
+	}
 }
 
-// 1.isValid
-// 2. revoke the old token
-// 3. Generate and save the new refresh token and return it
-// 4. Generate new access token and return it
+// 1. rotate the old token in a single transaction, a reused token revokes its whole family
+// 2. Generate new access token and return it
 func (c *ControllerImpl) refresh(ctx *gin.Context) {
-	// get the refresh token from client
-	refreshToken, err := ctx.Cookie("refreshToken")
+	// get the refresh token from client, the cookie for browsers or the body for non-browser clients
+	refreshToken, err := utils.GetRefreshToken(ctx)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"message": "refresh failed " + err.Error(),
@@ -53,27 +69,34 @@ func (c *ControllerImpl) refresh(ctx *gin.Context) {
 		return
 	}
 
-	// get the refresh token from database
-	oldRefreshToken, err := c.service.getBy(refreshToken)
+	// 1. rotate the old token
+	oldRefreshToken, newRefreshToken, err := c.service.rotate(refreshToken, audit.NewOrigin(ctx))
 	if err != nil {
+		if errors.Is(err, ErrTokenReused) {
+			utils.ClearTokens(ctx)
+			ctx.JSON(http.StatusUnauthorized, gin.H{
+				"message": "refresh failed! " + err.Error(),
+			})
+			return
+		}
+
 		ctx.JSON(http.StatusBadRequest, gin.H{
-			"message": "refresh failed " + err.Error(),
+			"message": "refresh failed! " + err.Error(),
 		})
 		return
 	}
 
-	// 1. isValid
-	// checking if refresh token is not expired and not revoked
-	err = c.service.isValid(oldRefreshToken)
+	// 2. Generate new access token and return it
+	// Roles are reloaded so role changes are applied on refresh
+	roles, permissions, err := c.roleService.GetClaims(oldRefreshToken.UserId)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
+		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "refresh failed! " + err.Error(),
 		})
 		return
 	}
 
-	// 2. revoke the old token
-	_, err = c.service.revoke(oldRefreshToken.Id, oldRefreshToken.UserId)
+	accessToken, err := middleware.GenerateJWT(oldRefreshToken.UserId, roles, permissions)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"message": "refresh failed! " + err.Error(),
@@ -81,62 +104,102 @@ func (c *ControllerImpl) refresh(ctx *gin.Context) {
 		return
 	}
 
-	// 3. Generate and save the new refresh token and return it
-	newRefreshToken, err := c.service.SaveWith(oldRefreshToken.UserId, oldRefreshToken.ExpiresAt)
+	err = utils.SendTokens(ctx, accessToken, newRefreshToken, "refreshing access token successful")
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
+		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "refresh failed! " + err.Error(),
 		})
 		return
 	}
+}
 
-	// 4. Generate new access token and return it
-	accessToken, err := middleware.GenerateJWT(oldRefreshToken.UserId)
+func (c *ControllerImpl) getAllBy(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
-			"message": "refresh failed! " + err.Error(),
+			"message": "get all by failed " + err.Error(),
 		})
 		return
 	}
 
-	err = utils.SetTokens(ctx, accessToken, newRefreshToken)
+	refreshTokens, err := c.service.getAllBy(sub)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"message": "refresh failed! " + err.Error(),
+			"message": "get all by failed " + err.Error(),
 		})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"message": "refreshing access token successful",
-	})
+	ctx.JSON(http.StatusOK, refreshTokens)
 }
 
-func (c *ControllerImpl) getAllBy(ctx *gin.Context) {
+func (c *ControllerImpl) revoke(ctx *gin.Context) {
 	sub, err := middleware.GetSubject(ctx)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
-			"message": "get all by failed " + err.Error(),
+			"message": "save failed " + err.Error(),
 		})
 		return
 	}
 
-	refreshTokens, err := c.service.getAllBy(sub)
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "revoke failed " + err.Error(),
+		})
+		return
+	}
+	_, err = c.service.revoke(id, sub, audit.NewOrigin(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "revoke failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+func (c *ControllerImpl) getAllSessions(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all sessions failed " + err.Error(),
+		})
+		return
+	}
+
+	page := ctx.DefaultQuery("page", "1")
+	pageSize := ctx.DefaultQuery("pageSize", "10")
+	field := ctx.DefaultQuery("field", "last_used_at")
+	sortBy := ctx.DefaultQuery("sortBy", "DESC")
+	request, err := paging.NewPageRequestStr(page, pageSize, field, sortBy)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all sessions failed " + err.Error(),
+		})
+		return
+	}
+
+	// Used to mark the current session, it's fine if there's none
+	refreshToken := utils.GetCurrentRefreshToken(ctx)
+
+	sessions, err := c.service.getAllSessions(sub, refreshToken, request)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"message": "get all by failed " + err.Error(),
+			"message": "get all sessions failed " + err.Error(),
 		})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, refreshTokens)
+	ctx.JSON(http.StatusOK, sessions)
 }
 
-func (c *ControllerImpl) revoke(ctx *gin.Context) {
+func (c *ControllerImpl) revokeSession(ctx *gin.Context) {
 	sub, err := middleware.GetSubject(ctx)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
-			"message": "save failed " + err.Error(),
+			"message": "revoke session failed " + err.Error(),
 		})
 		return
 	}
@@ -144,17 +207,48 @@ func (c *ControllerImpl) revoke(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
-			"message": "revoke failed " + err.Error(),
+			"message": "revoke session failed " + err.Error(),
 		})
 		return
 	}
-	_, err = c.service.revoke(id, sub)
+
+	_, err = c.service.revokeSession(id, sub, audit.NewOrigin(ctx))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
-			"message": "revoke failed " + err.Error(),
+			"message": "revoke session failed " + err.Error(),
 		})
 		return
 	}
 
 	ctx.JSON(http.StatusNoContent, nil)
 }
+
+func (c *ControllerImpl) revokeOtherSessions(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "logout others failed " + err.Error(),
+		})
+		return
+	}
+
+	refreshToken, err := utils.GetRefreshToken(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "logout others failed " + err.Error(),
+		})
+		return
+	}
+
+	affectedRows, err := c.service.revokeOtherSessions(sub, refreshToken, audit.NewOrigin(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "logout others failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"revoked": affectedRows,
+	})
+}
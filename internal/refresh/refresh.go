@@ -5,13 +5,25 @@ import (
 	"time"
 )
 
+// Revoked reasons
+const (
+	ROTATED        = "rotated"
+	REUSE_DETECTED = "reuse_detected"
+	LOGOUT         = "logout"
+	REVOKED        = "revoked"
+	REVOKED_ALL    = "revoked_all"
+)
+
+// Token every rotated token keeps the family id of the login that created it
 type Token struct {
-	Id        int          `json:"id" db:"id"`
-	CreatedAt time.Time    `json:"created_at" db:"created_at"`
-	Token     string       `json:"-" db:"token"`
-	ExpiresAt sql.NullTime `json:"expires_at" db:"expires_at"`
-	RevokedAt sql.NullTime `json:"revoked_at" db:"revoked_at"`
-	UserId    int          `json:"user_id" db:"user_id"`
+	Id            int            `json:"id" db:"id"`
+	CreatedAt     time.Time      `json:"created_at" db:"created_at"`
+	Token         string         `json:"-" db:"token"`
+	FamilyId      string         `json:"family_id" db:"family_id"`
+	ExpiresAt     sql.NullTime   `json:"expires_at" db:"expires_at"`
+	RevokedAt     sql.NullTime   `json:"revoked_at" db:"revoked_at"`
+	RevokedReason sql.NullString `json:"revoked_reason" db:"revoked_reason"`
+	UserId        int            `json:"user_id" db:"user_id"`
 }
 
 func (t Token) IsExpired() bool {
@@ -21,3 +33,8 @@ func (t Token) IsExpired() bool {
 func (t Token) IsRevoked() bool {
 	return t.RevokedAt.Valid // If theres a value it is revoked
 }
+
+// IsRotated only rotated tokens are replaced by a newer one, so using them again means they were stolen
+func (t Token) IsRotated() bool {
+	return t.IsRevoked() && t.RevokedReason.Valid && t.RevokedReason.String == ROTATED
+}
@@ -1,25 +1,34 @@
 package refresh
 
 import (
-	"database/sql"
+	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"log"
 	"os"
+	"social-media-application/internal/paging"
+	"social-media-application/utils"
 	"strconv"
 	"time"
 )
 
+var ErrTokenReused = errors.New("token reuse detected, please login again")
+
 type (
 	Repository interface {
-		save(userId int) (token string, err error)
-		saveWith(userId int, expiresAt sql.NullTime) (token string, err error)
+		save(userId int, metadata Metadata) (token string, err error)
+		rotate(token string, ipAddress string) (old Token, newToken string, err error)
 
 		findBy(token string) (Token, error)
-
 		findAllBy(userId int) ([]Token, error)
+		findAllSessions(userId int, currentFamilyId string, request *paging.PageRequest) (*paging.Page[Session], error)
 
 		revoke(id int, userId int) (affectedRows int64, err error)
 		revokeByToken(token string) (affectedRows int64, err error)
+		revokeAllBy(userId int) (affectedRows int64, err error)
+		revokeSession(id int, userId int) (affectedRows int64, err error)
+		revokeAllExcept(userId int, familyId string) (affectedRows int64, err error)
 	}
 
 	RepositoryImpl struct {
@@ -33,15 +42,29 @@ func NewRepository(db *sqlx.DB) Repository {
 	}
 }
 
-func (repository RepositoryImpl) save(userId int) (token string, err error) {
+func (repository RepositoryImpl) save(userId int, metadata Metadata) (token string, err error) {
 	tokenExpiration, err := strconv.Atoi(os.Getenv("REFRESH_TOKEN_EXPIRATION_IN_DAYS"))
 	if err != nil {
 		return "", err
 	}
 
+	tx, err := repository.Beginx()
+	if err != nil {
+		return "", err
+	}
+	defer func(tx *sqlx.Tx) {
+		err := tx.Rollback()
+		if err != nil {
+			return
+		}
+	}(tx)
+
+	// Every login starts a new family and session
 	token = uuid.New().String()
-	_, err = repository.NamedExec("INSERT INTO refresh_token(token, expires_at, user_id) VALUES (:token, :expiresAt, :userId)", map[string]any{
+	familyId := uuid.New().String()
+	_, err = tx.NamedExec("INSERT INTO refresh_token(token, family_id, expires_at, user_id) VALUES (:token, :familyId, :expiresAt, :userId)", map[string]any{
 		"token":     token,
+		"familyId":  familyId,
 		"expiresAt": time.Now().AddDate(0, 0, tokenExpiration),
 		"userId":    userId,
 	})
@@ -49,21 +72,106 @@ func (repository RepositoryImpl) save(userId int) (token string, err error) {
 		return "", err
 	}
 
+	_, err = tx.NamedExec("INSERT INTO session(family_id, device_name, user_agent, ip_address, login_method, user_id) VALUES (:familyId, :deviceName, :userAgent, :ipAddress, :loginMethod, :userId)", map[string]any{
+		"familyId":    familyId,
+		"deviceName":  metadata.DeviceName,
+		"userAgent":   metadata.UserAgent,
+		"ipAddress":   metadata.IpAddress,
+		"loginMethod": metadata.LoginMethod,
+		"userId":      userId,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return "", err
+	}
+
 	return token, nil
 }
 
-func (repository RepositoryImpl) saveWith(userId int, expiresAt sql.NullTime) (token string, err error) {
-	token = uuid.New().String()
-	_, err = repository.NamedExec("INSERT INTO refresh_token(token, expires_at, user_id) VALUES (:token, :expiresAt, :userId)", map[string]any{
-		"token":     token,
-		"expiresAt": expiresAt,
-		"userId":    userId,
+// rotate revokes the old token and saves the new one in the same family in a single transaction
+// the old token is locked so two refreshes at the same time cannot both succeed
+// a rotated token means it was already used, so it was stolen and the whole family is revoked
+// tokens revoked any other way like logout or password reset are only rejected
+func (repository RepositoryImpl) rotate(token string, ipAddress string) (old Token, newToken string, err error) {
+	tx, err := repository.Beginx()
+	if err != nil {
+		return Token{}, "", err
+	}
+	defer func(tx *sqlx.Tx) {
+		err := tx.Rollback()
+		if err != nil {
+			return
+		}
+	}(tx)
+
+	err = tx.Get(&old, "SELECT * FROM refresh_token WHERE token = ? FOR UPDATE", token)
+	if err != nil {
+		return Token{}, "", err
+	}
+
+	if old.IsRotated() {
+		_, err = tx.NamedExec("UPDATE refresh_token SET revoked_at = NOW(), revoked_reason = :reason WHERE family_id = :familyId AND revoked_at IS NULL", map[string]any{
+			"reason":   REUSE_DETECTED,
+			"familyId": old.FamilyId,
+		})
+		if err != nil {
+			return Token{}, "", err
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			return Token{}, "", err
+		}
+
+		return old, "", ErrTokenReused
+	}
+
+	if old.IsRevoked() {
+		return Token{}, "", errors.New("token is revoked")
+	}
+
+	if old.IsExpired() {
+		return Token{}, "", errors.New("token is expired")
+	}
+
+	_, err = tx.NamedExec("UPDATE refresh_token SET revoked_at = NOW(), revoked_reason = :reason WHERE id = :id", map[string]any{
+		"reason": ROTATED,
+		"id":     old.Id,
 	})
 	if err != nil {
-		return "", err
+		return Token{}, "", err
 	}
 
-	return token, nil
+	// The new token keeps the expiration of the login so refreshing cannot extend it forever
+	newToken = uuid.New().String()
+	_, err = tx.NamedExec("INSERT INTO refresh_token(token, family_id, expires_at, user_id) VALUES (:token, :familyId, :expiresAt, :userId)", map[string]any{
+		"token":     newToken,
+		"familyId":  old.FamilyId,
+		"expiresAt": old.ExpiresAt,
+		"userId":    old.UserId,
+	})
+	if err != nil {
+		return Token{}, "", err
+	}
+
+	_, err = tx.NamedExec("UPDATE session SET last_used_at = NOW(), ip_address = :ipAddress WHERE family_id = :familyId", map[string]any{
+		"ipAddress": ipAddress,
+		"familyId":  old.FamilyId,
+	})
+	if err != nil {
+		return Token{}, "", err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return Token{}, "", err
+	}
+
+	return old, newToken, nil
 }
 
 func (repository RepositoryImpl) findBy(token string) (Token, error) {
@@ -86,8 +194,44 @@ func (repository RepositoryImpl) findAllBy(userId int) ([]Token, error) {
 	return tokens, nil
 }
 
+// findAllSessions only returns sessions that still have an active refresh token
+func (repository RepositoryImpl) findAllSessions(userId int, currentFamilyId string, request *paging.PageRequest) (*paging.Page[Session], error) {
+	if !utils.IsInDBTag(request.Field, Session{}) {
+		request.Field = "last_used_at"
+		log.Println("WARNING: field is not in database! defaulted to", request.Field)
+	}
+
+	if !utils.IsInSortingOrder(request.SortBy) {
+		request.SortBy = "DESC"
+		log.Println("WARNING: sortBy is not valid! defaulted to", request.SortBy)
+	}
+
+	const active = `
+		s.user_id = ?
+		AND EXISTS(
+			SELECT 1 FROM refresh_token rt
+			WHERE rt.family_id = s.family_id AND rt.revoked_at IS NULL AND rt.expires_at > NOW()
+		)`
+
+	var total int
+	err := repository.Get(&total, "SELECT COUNT(*) FROM session s WHERE"+active, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]Session, 0, request.PageSize)
+	query := fmt.Sprintf("SELECT s.*, s.family_id = ? AS is_current FROM session s WHERE %s ORDER BY %s %s LIMIT ? OFFSET ?", active, request.Field, request.SortBy)
+	err = repository.Select(&sessions, query, currentFamilyId, userId, request.PageSize, request.Offset())
+	if err != nil {
+		return nil, err
+	}
+
+	return paging.NewPage(sessions, request, total), nil
+}
+
 func (repository RepositoryImpl) revoke(id int, userId int) (affectedRows int64, err error) {
-	result, err := repository.NamedExec("UPDATE refresh_token SET revoked_at = NOW() WHERE id = :id AND user_id = :userId", map[string]any{
+	result, err := repository.NamedExec("UPDATE refresh_token SET revoked_at = NOW(), revoked_reason = :reason WHERE id = :id AND user_id = :userId AND revoked_at IS NULL", map[string]any{
+		"reason": REVOKED,
 		"id":     id,
 		"userId": userId,
 	})
@@ -104,8 +248,66 @@ func (repository RepositoryImpl) revoke(id int, userId int) (affectedRows int64,
 }
 
 func (repository RepositoryImpl) revokeByToken(token string) (affectedRows int64, err error) {
-	result, err := repository.NamedExec("UPDATE refresh_token SET revoked_at = NOW() WHERE token = :token", map[string]any{
-		"token": token,
+	result, err := repository.NamedExec("UPDATE refresh_token SET revoked_at = NOW(), revoked_reason = :reason WHERE token = :token AND revoked_at IS NULL", map[string]any{
+		"reason": LOGOUT,
+		"token":  token,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
+func (repository RepositoryImpl) revokeAllBy(userId int) (affectedRows int64, err error) {
+	result, err := repository.NamedExec("UPDATE refresh_token SET revoked_at = NOW(), revoked_reason = :reason WHERE user_id = :userId AND revoked_at IS NULL", map[string]any{
+		"reason": REVOKED_ALL,
+		"userId": userId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
+func (repository RepositoryImpl) revokeSession(id int, userId int) (affectedRows int64, err error) {
+	result, err := repository.NamedExec(`
+		UPDATE refresh_token rt
+		JOIN session s ON s.family_id = rt.family_id
+		SET rt.revoked_at = NOW(), rt.revoked_reason = :reason
+		WHERE s.id = :id AND s.user_id = :userId AND rt.revoked_at IS NULL`, map[string]any{
+		"reason": LOGOUT,
+		"id":     id,
+		"userId": userId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
+func (repository RepositoryImpl) revokeAllExcept(userId int, familyId string) (affectedRows int64, err error) {
+	result, err := repository.NamedExec("UPDATE refresh_token SET revoked_at = NOW(), revoked_reason = :reason WHERE user_id = :userId AND family_id != :familyId AND revoked_at IS NULL", map[string]any{
+		"reason":   LOGOUT,
+		"userId":   userId,
+		"familyId": familyId,
 	})
 	if err != nil {
 		return 0, err
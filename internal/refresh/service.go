@@ -1,87 +1,85 @@
 package refresh
 
 import (
-	"database/sql"
 	"errors"
 	"log"
+	"social-media-application/internal/audit"
+	"social-media-application/internal/paging"
 	"strings"
 )
 
 type (
 	Service interface {
-		isValid(token Token) error
+		Save(userId int, metadata Metadata) (token string, err error)
+		rotate(token string, origin audit.Origin) (old Token, newToken string, err error)
 
-		Save(userId int) (token string, err error)
-		SaveWith(userId int, expiresAt sql.NullTime) (token string, err error)
-
-		getBy(token string) (Token, error)
 		getAllBy(userId int) ([]Token, error)
+		getAllSessions(userId int, currentToken string, request *paging.PageRequest) (*paging.Page[Session], error)
 
-		revoke(id int, userId int) (affectedRows int64, err error)
-		RevokeByToken(token string) (affectedRows int64, err error)
+		revoke(id int, userId int, origin audit.Origin) (affectedRows int64, err error)
+		RevokeByToken(token string, origin audit.Origin) (affectedRows int64, err error)
+		RevokeAllBy(userId int, origin audit.Origin) (affectedRows int64, err error) // logs out the user from every device
+		revokeSession(id int, userId int, origin audit.Origin) (affectedRows int64, err error)
+		revokeOtherSessions(userId int, currentToken string, origin audit.Origin) (affectedRows int64, err error)
 	}
 
 	ServiceImpl struct {
-		repository Repository
+		repository   Repository
+		auditService audit.Service
 	}
 )
 
-func NewService(repository Repository) Service {
+func NewService(repository Repository, auditService audit.Service) Service {
 	return &ServiceImpl{
-		repository: repository,
-	}
-}
-
-func (s ServiceImpl) isValid(token Token) error {
-	if token.IsRevoked() {
-		log.Print("Accessing illegal token") // add a panic, recover, and defer here to log
-		return errors.New("token is revoked")
-	}
-
-	if token.IsExpired() {
-		return errors.New("token is expired")
+		repository:   repository,
+		auditService: auditService,
 	}
-
-	return nil
 }
 
-func (s ServiceImpl) Save(userId int) (token string, err error) {
+func (s ServiceImpl) Save(userId int, metadata Metadata) (token string, err error) {
 	if userId <= 0 {
 		return "", errors.New("userId is invalid")
 	}
 
-	token, err = s.repository.save(userId)
-	if err != nil {
-		return "", err
-	}
-
-	return token, nil
-}
-
-func (s ServiceImpl) SaveWith(userId int, expiresAt sql.NullTime) (token string, err error) {
-	if userId <= 0 {
-		return "", errors.New("userId is invalid")
+	if strings.TrimSpace(metadata.LoginMethod) == "" {
+		return "", errors.New("login method is required")
 	}
 
-	token, err = s.repository.saveWith(userId, expiresAt)
+	token, err = s.repository.save(userId, metadata)
 	if err != nil {
 		return "", err
 	}
 
+	// Every login method ends here so this is the only place successful logins are recorded
+	s.auditService.Record(audit.LOGIN, userId, audit.Origin{
+		ActorId:   userId,
+		IpAddress: metadata.IpAddress,
+		UserAgent: metadata.UserAgent,
+	}, audit.Metadata{
+		"login_method": metadata.LoginMethod,
+		"device_name":  metadata.DeviceName,
+	})
+
 	return token, nil
 }
 
-func (s ServiceImpl) getBy(token string) (Token, error) {
+func (s ServiceImpl) rotate(token string, origin audit.Origin) (old Token, newToken string, err error) {
 	if strings.TrimSpace(token) == "" {
-		return Token{}, errors.New("token is empty")
+		return Token{}, "", errors.New("token is empty")
 	}
 
-	refresh, err := s.repository.findBy(token)
+	old, newToken, err = s.repository.rotate(token, origin.IpAddress)
 	if err != nil {
-		return Token{}, err
+		if errors.Is(err, ErrTokenReused) {
+			log.Printf("security event: refresh token reuse detected, revoked family %s of user %d", old.FamilyId, old.UserId)
+			s.auditService.Record(audit.REFRESH_TOKEN_REUSED, old.UserId, origin, audit.Metadata{
+				"family_id": old.FamilyId,
+			})
+		}
+		return Token{}, "", err
 	}
 
-	return refresh, nil
+	return old, newToken, nil
 }
 
 func (s ServiceImpl) getAllBy(userId int) ([]Token, error) {
@@ -97,7 +95,7 @@ func (s ServiceImpl) getAllBy(userId int) ([]Token, error) {
 	return refreshTokens, nil
 }
 
-func (s ServiceImpl) revoke(id int, userId int) (affectedRows int64, err error) {
+func (s ServiceImpl) revoke(id int, userId int, origin audit.Origin) (affectedRows int64, err error) {
 	if id <= 0 {
 		return 0, errors.New("token is empty")
 	}
@@ -111,14 +109,30 @@ func (s ServiceImpl) revoke(id int, userId int) (affectedRows int64, err error)
 		return 0, errors.New("no affected rows")
 	}
 
+	s.auditService.Record(audit.REFRESH_TOKEN_REVOKED, userId, origin, audit.Metadata{
+		"reason":           REVOKED,
+		"refresh_token_id": id,
+	})
+
 	return affectedRows, nil
 }
 
-func (s ServiceImpl) RevokeByToken(token string) (affectedRows int64, err error) {
+func (s ServiceImpl) RevokeByToken(token string, origin audit.Origin) (affectedRows int64, err error) {
 	if strings.TrimSpace(token) == "" {
 		return 0, errors.New("token is empty")
 	}
 
+	// Only needed to know whose token it is
+	revoked, err := s.repository.findBy(token)
+	if err != nil {
+		return 0, err
+	}
+
+	// Revoked tokens keep their reason so a rotated one is still detected when it's reused
+	if revoked.IsRevoked() {
+		return 0, nil
+	}
+
 	affectedRows, err = s.repository.revokeByToken(token)
 	if err != nil {
 		return 0, err
@@ -128,5 +142,117 @@ func (s ServiceImpl) RevokeByToken(token string) (affectedRows int64, err error)
 		return 0, errors.New("no affected rows")
 	}
 
+	s.auditService.Record(audit.REFRESH_TOKEN_REVOKED, revoked.UserId, origin.As(revoked.UserId), audit.Metadata{
+		"reason":    LOGOUT,
+		"family_id": revoked.FamilyId,
+	})
+
+	return affectedRows, nil
+}
+
+func (s ServiceImpl) RevokeAllBy(userId int, origin audit.Origin) (affectedRows int64, err error) {
+	if userId <= 0 {
+		return 0, errors.New("userId is invalid")
+	}
+
+	// No affected rows only means the user has no active refresh token
+	affectedRows, err = s.repository.revokeAllBy(userId)
+	if err != nil {
+		return 0, err
+	}
+
+	s.auditService.Record(audit.REFRESH_TOKEN_REVOKED, userId, origin, audit.Metadata{
+		"reason":  REVOKED_ALL,
+		"revoked": affectedRows,
+	})
+
+	return affectedRows, nil
+}
+
+func (s ServiceImpl) getAllSessions(userId int, currentToken string, request *paging.PageRequest) (*paging.Page[Session], error) {
+	if userId <= 0 {
+		return nil, errors.New("userId is invalid")
+	}
+
+	// The current session is only marked, a missing or foreign token is not an error here
+	currentFamilyId := ""
+	current, err := s.currentOf(userId, currentToken)
+	if err == nil {
+		currentFamilyId = current.FamilyId
+	}
+
+	sessions, err := s.repository.findAllSessions(userId, currentFamilyId, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (s ServiceImpl) revokeSession(id int, userId int, origin audit.Origin) (affectedRows int64, err error) {
+	if id <= 0 {
+		return 0, errors.New("session id is required")
+	}
+
+	if userId <= 0 {
+		return 0, errors.New("userId is invalid")
+	}
+
+	affectedRows, err = s.repository.revokeSession(id, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	if affectedRows <= 0 {
+		return 0, errors.New("no affected rows")
+	}
+
+	s.auditService.Record(audit.REFRESH_TOKEN_REVOKED, userId, origin, audit.Metadata{
+		"reason":     "session_revoked",
+		"session_id": id,
+	})
+
 	return affectedRows, nil
 }
+
+// revokeOtherSessions keeps the session of the current refresh token and logs out every other device
+func (s ServiceImpl) revokeOtherSessions(userId int, currentToken string, origin audit.Origin) (affectedRows int64, err error) {
+	if userId <= 0 {
+		return 0, errors.New("userId is invalid")
+	}
+
+	current, err := s.currentOf(userId, currentToken)
+	if err != nil {
+		return 0, err
+	}
+
+	// No affected rows only means there are no other sessions
+	affectedRows, err = s.repository.revokeAllExcept(userId, current.FamilyId)
+	if err != nil {
+		return 0, err
+	}
+
+	s.auditService.Record(audit.REFRESH_TOKEN_REVOKED, userId, origin, audit.Metadata{
+		"reason":  "logout_others",
+		"revoked": affectedRows,
+	})
+
+	return affectedRows, nil
+}
+
+func (s ServiceImpl) currentOf(userId int, currentToken string) (Token, error) {
+	if strings.TrimSpace(currentToken) == "" {
+		return Token{}, errors.New("token is empty")
+	}
+
+	current, err := s.repository.findBy(currentToken)
+	if err != nil {
+		return Token{}, err
+	}
+
+	if current.UserId != userId || current.IsRevoked() || current.IsExpired() {
+		return Token{}, errors.New("token is not the current session")
+	}
+
+	return current, nil
+}
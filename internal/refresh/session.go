@@ -0,0 +1,113 @@
+package refresh
+
+import (
+	"github.com/gin-gonic/gin"
+	"strings"
+	"time"
+)
+
+// Login methods
+const (
+	PASSWORD = "password"
+	PASSKEY  = "passkey"
+)
+
+// Session is a device that logged in, it lives as long as its refresh token family
+type Session struct {
+	Id          int       `json:"id" db:"id"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	LastUsedAt  time.Time `json:"last_used_at" db:"last_used_at"`
+	FamilyId    string    `json:"-" db:"family_id"`
+	DeviceName  string    `json:"device_name" db:"device_name"`
+	UserAgent   string    `json:"user_agent" db:"user_agent"`
+	IpAddress   string    `json:"ip_address" db:"ip_address"`
+	LoginMethod string    `json:"login_method" db:"login_method"`
+	UserId      int       `json:"user_id" db:"user_id"`
+	IsCurrent   bool      `json:"is_current" db:"is_current"`
+}
+
+// Metadata is saved in the session when the user logs in
+type Metadata struct {
+	DeviceName  string
+	UserAgent   string
+	IpAddress   string
+	LoginMethod string
+}
+
+// NewMetadata non-browser clients can name themselves with the X-Device-Name header
+func NewMetadata(ctx *gin.Context, loginMethod string) Metadata {
+	userAgent := ctx.Request.UserAgent()
+
+	deviceName := strings.TrimSpace(ctx.GetHeader("X-Device-Name"))
+	if deviceName == "" {
+		deviceName = deviceNameOf(userAgent)
+	}
+
+	return Metadata{
+		DeviceName:  truncate(deviceName, 100),
+		UserAgent:   truncate(userAgent, 255),
+		IpAddress:   ctx.ClientIP(),
+		LoginMethod: loginMethod,
+	}
+}
+
+// SocialLoginMethod is the login method of a social provider like "social:google"
+func SocialLoginMethod(provider string) string {
+	return "social:" + strings.ToLower(provider)
+}
+
+// The order matters since most user agents mention other browsers and systems
+var (
+	browsers = [][2]string{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	}
+
+	operatingSystems = [][2]string{
+		{"Windows", "Windows"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Android", "Android"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	}
+)
+
+// deviceNameOf returns a readable name like "Chrome on Windows"
+func deviceNameOf(userAgent string) string {
+	browser := firstMatch(userAgent, browsers)
+	os := firstMatch(userAgent, operatingSystems)
+
+	switch {
+	case browser != "" && os != "":
+		return browser + " on " + os
+	case browser != "":
+		return browser
+	case os != "":
+		return os
+	default:
+		return "Unknown device"
+	}
+}
+
+func firstMatch(userAgent string, names [][2]string) string {
+	for _, name := range names {
+		if strings.Contains(userAgent, name[0]) {
+			return name[1]
+		}
+	}
+
+	return ""
+}
+
+func truncate(value string, length int) string {
+	runes := []rune(value)
+	if len(runes) <= length {
+		return value
+	}
+
+	return string(runes[:length])
+}
@@ -0,0 +1,159 @@
+package role
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"social-media-application/middlewares"
+	"strconv"
+)
+
+type (
+	Controller interface {
+		getAll(ctx *gin.Context)
+		getAllPermissions(ctx *gin.Context)
+		getAllByUser(ctx *gin.Context)
+
+		assign(ctx *gin.Context)
+		revoke(ctx *gin.Context)
+
+		RegisterRoutes(e *gin.Engine)
+	}
+
+	ControllerImpl struct {
+		service Service
+	}
+)
+
+func NewController(service Service) Controller {
+	return &ControllerImpl{
+		service: service,
+	}
+}
+
+func (c ControllerImpl) RegisterRoutes(e *gin.Engine) {
+	r := e.Group("/roles", middleware.JWT, middleware.HasPermission(ROLES_MANAGE))
+	{
+		r.GET("", c.getAll)
+		r.GET("/:id/permissions", c.getAllPermissions)
+		r.GET("/users/:userId", c.getAllByUser)
+
+		r.POST("/:id/users/:userId", c.assign)
+		r.DELETE("/:id/users/:userId", c.revoke)
+	}
+}
+
+func (c ControllerImpl) getAll(ctx *gin.Context) {
+	roles, err := c.service.getAll()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "get all failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, roles)
+}
+
+func (c ControllerImpl) getAllPermissions(ctx *gin.Context) {
+	roleId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all permissions failed " + err.Error(),
+		})
+		return
+	}
+
+	permissions, err := c.service.getAllPermissions(roleId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "get all permissions failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, permissions)
+}
+
+func (c ControllerImpl) getAllByUser(ctx *gin.Context) {
+	userId, err := strconv.Atoi(ctx.Param("userId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all by user failed " + err.Error(),
+		})
+		return
+	}
+
+	roles, err := c.service.getAllByUser(userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "get all by user failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, roles)
+}
+
+func (c ControllerImpl) assign(ctx *gin.Context) {
+	roleId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "assign failed " + err.Error(),
+		})
+		return
+	}
+
+	userId, err := strconv.Atoi(ctx.Param("userId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "assign failed " + err.Error(),
+		})
+		return
+	}
+
+	id, err := c.service.assign(userId, roleId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "assign failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, id)
+}
+
+func (c ControllerImpl) revoke(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "revoke failed " + err.Error(),
+		})
+		return
+	}
+
+	roleId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "revoke failed " + err.Error(),
+		})
+		return
+	}
+
+	userId, err := strconv.Atoi(ctx.Param("userId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "revoke failed " + err.Error(),
+		})
+		return
+	}
+
+	_, err = c.service.revoke(sub, userId, roleId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "revoke failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,147 @@
+package role
+
+import (
+	"github.com/jmoiron/sqlx"
+)
+
+type (
+	Repository interface {
+		findById(roleId int) (Role, error)
+		findAll() ([]Role, error)
+		findAllPermissions(roleId int) ([]Permission, error)
+		findAllByUser(userId int) ([]Role, error)
+
+		findPermissionNamesByUser(userId int) ([]string, error)
+
+		assign(userId, roleId int) (id int64, err error)
+		revoke(userId, roleId int) (affectedRows int64, err error)
+
+		isAssigned(userId, roleId int) (bool, error)
+	}
+
+	RepositoryImpl struct {
+		*sqlx.DB
+	}
+)
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &RepositoryImpl{
+		DB: db,
+	}
+}
+
+func (repository RepositoryImpl) findById(roleId int) (Role, error) {
+	var role Role
+	err := repository.Get(&role, "SELECT * FROM role WHERE id = ?", roleId)
+	if err != nil {
+		return Role{}, err
+	}
+
+	return role, nil
+}
+
+func (repository RepositoryImpl) findAll() ([]Role, error) {
+	roles := make([]Role, 0, 10)
+	err := repository.Select(&roles, "SELECT * FROM role")
+	if err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (repository RepositoryImpl) findAllPermissions(roleId int) ([]Permission, error) {
+	query := `
+		SELECT p.*
+		FROM permission p
+		JOIN role_permission rp ON rp.permission_id = p.id
+		WHERE rp.role_id = ?
+	`
+
+	permissions := make([]Permission, 0, 10)
+	err := repository.Select(&permissions, query, roleId)
+	if err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (repository RepositoryImpl) findAllByUser(userId int) ([]Role, error) {
+	query := `
+		SELECT r.*
+		FROM role r
+		JOIN user_role ur ON ur.role_id = r.id
+		WHERE ur.user_id = ?
+	`
+
+	roles := make([]Role, 0, 10)
+	err := repository.Select(&roles, query, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (repository RepositoryImpl) findPermissionNamesByUser(userId int) ([]string, error) {
+	query := `
+		SELECT DISTINCT p.name
+		FROM permission p
+		JOIN role_permission rp ON rp.permission_id = p.id
+		JOIN user_role ur ON ur.role_id = rp.role_id
+		WHERE ur.user_id = ?
+	`
+
+	permissions := make([]string, 0, 10)
+	err := repository.Select(&permissions, query, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (repository RepositoryImpl) assign(userId, roleId int) (id int64, err error) {
+	result, err := repository.NamedExec("INSERT INTO user_role (user_id, role_id) VALUES (:userId, :roleId)", map[string]any{
+		"userId": userId,
+		"roleId": roleId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	id, err = result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (repository RepositoryImpl) revoke(userId, roleId int) (affectedRows int64, err error) {
+	result, err := repository.NamedExec("DELETE FROM user_role WHERE user_id = :userId AND role_id = :roleId", map[string]any{
+		"userId": userId,
+		"roleId": roleId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
+func (repository RepositoryImpl) isAssigned(userId, roleId int) (bool, error) {
+	var exists bool
+	err := repository.Get(&exists, "SELECT EXISTS(SELECT 1 FROM user_role WHERE user_id = ? AND role_id = ?)", userId, roleId)
+	if err != nil {
+		return exists, err
+	}
+
+	return exists, nil
+}
@@ -0,0 +1,29 @@
+package role
+
+import "time"
+
+const (
+	ADMIN = "ADMIN"
+)
+
+// Permissions seeded by the migration, ADMIN has all of them
+const (
+	USERS_MANAGE = "users:manage"
+	EMOJIS_WRITE = "emojis:write"
+	ROLES_MANAGE = "roles:manage"
+	AUDIT_READ   = "audit:read"
+)
+
+type (
+	Role struct {
+		Id        int       `json:"id" db:"id"`
+		CreatedAt time.Time `json:"created_at" db:"created_at"`
+		Name      string    `json:"name" db:"name"`
+	}
+
+	Permission struct {
+		Id        int       `json:"id" db:"id"`
+		CreatedAt time.Time `json:"created_at" db:"created_at"`
+		Name      string    `json:"name" db:"name"`
+	}
+)
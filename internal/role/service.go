@@ -0,0 +1,143 @@
+package role
+
+import (
+	"errors"
+)
+
+type (
+	Service interface {
+		getAll() ([]Role, error)
+		getAllPermissions(roleId int) ([]Permission, error)
+		getAllByUser(userId int) ([]Role, error)
+
+		assign(userId, roleId int) (id int64, err error)
+		revoke(currentUserId, userId, roleId int) (affectedRows int64, err error)
+
+		GetClaims(userId int) (roles []string, permissions []string, err error) // used when generating the access token
+	}
+
+	ServiceImpl struct {
+		repository Repository
+	}
+)
+
+func NewService(repository Repository) Service {
+	return &ServiceImpl{
+		repository: repository,
+	}
+}
+
+func (s ServiceImpl) getAll() ([]Role, error) {
+	roles, err := s.repository.findAll()
+	if err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (s ServiceImpl) getAllPermissions(roleId int) ([]Permission, error) {
+	if roleId <= 0 {
+		return nil, errors.New("role id is required")
+	}
+
+	permissions, err := s.repository.findAllPermissions(roleId)
+	if err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (s ServiceImpl) getAllByUser(userId int) ([]Role, error) {
+	if userId <= 0 {
+		return nil, errors.New("user id is required")
+	}
+
+	roles, err := s.repository.findAllByUser(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (s ServiceImpl) assign(userId, roleId int) (id int64, err error) {
+	if userId <= 0 {
+		return 0, errors.New("user id is required")
+	}
+
+	if roleId <= 0 {
+		return 0, errors.New("role id is required")
+	}
+
+	isAssigned, err := s.repository.isAssigned(userId, roleId)
+	if err != nil {
+		return 0, err
+	}
+
+	if isAssigned {
+		return 0, errors.New("role already assigned to user")
+	}
+
+	id, err = s.repository.assign(userId, roleId)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (s ServiceImpl) revoke(currentUserId, userId, roleId int) (affectedRows int64, err error) {
+	if userId <= 0 {
+		return 0, errors.New("user id is required")
+	}
+
+	if roleId <= 0 {
+		return 0, errors.New("role id is required")
+	}
+
+	role, err := s.repository.findById(roleId)
+	if err != nil {
+		return 0, err
+	}
+
+	// So the last admin cannot lock everyone out of role management
+	if role.Name == ADMIN && currentUserId == userId {
+		return 0, errors.New("cannot revoke your own admin role")
+	}
+
+	affectedRows, err = s.repository.revoke(userId, roleId)
+	if err != nil {
+		return 0, err
+	}
+
+	if affectedRows <= 0 {
+		return 0, errors.New("role is not assigned to user")
+	}
+
+	return affectedRows, nil
+}
+
+func (s ServiceImpl) GetClaims(userId int) (roles []string, permissions []string, err error) {
+	if userId <= 0 {
+		return nil, nil, errors.New("user id is required")
+	}
+
+	userRoles, err := s.repository.findAllByUser(userId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	roles = make([]string, 0, len(userRoles))
+	for _, role := range userRoles {
+		roles = append(roles, role.Name)
+	}
+
+	permissions, err = s.repository.findPermissionNamesByUser(userId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return roles, permissions, nil
+}
@@ -0,0 +1,104 @@
+package core
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/url"
+	"os"
+	"social-media-application/internal/refresh"
+	"social-media-application/internal/role"
+	"social-media-application/internal/totp"
+	middleware "social-media-application/middlewares"
+	"social-media-application/utils"
+)
+
+// Authenticator issues the same cookies for every social provider once the provider user is resolved
+type Authenticator struct {
+	refreshService refresh.Service
+	roleService    role.Service
+	totpService    totp.Service
+}
+
+func NewAuthenticator(refreshService refresh.Service, roleService role.Service, totpService totp.Service) *Authenticator {
+	return &Authenticator{
+		refreshService: refreshService,
+		roleService:    roleService,
+		totpService:    totpService,
+	}
+}
+
+// Authenticate sends the user to the two factor page instead when it's enabled
+// redirectTo is passed along so the two factor page knows where to go next
+// provider is saved as the login method of the session
+func (a Authenticator) Authenticate(ctx *gin.Context, userId int, provider, redirectTo string) {
+	isTotpEnabled, err := a.totpService.IsEnabled(userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "login failed! " + err.Error(),
+		})
+		return
+	}
+
+	if isTotpEnabled {
+		challenge, err := middleware.GenerateChallenge(userId, refresh.SocialLoginMethod(provider))
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"message": "login failed! " + err.Error(),
+			})
+			return
+		}
+
+		twoFactorUrl, err := url.Parse(os.Getenv("FRONT_END_TWO_FACTOR_URL"))
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"message": "login failed! " + err.Error(),
+			})
+			return
+		}
+
+		query := twoFactorUrl.Query()
+		query.Set("redirect_to", redirectTo)
+		twoFactorUrl.RawQuery = query.Encode()
+
+		utils.SetChallenge(ctx, challenge)
+		ctx.Redirect(http.StatusFound, twoFactorUrl.String())
+		return
+	}
+
+	accessToken, refreshToken, err := a.generateTokens(ctx, userId, provider)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "login failed! " + err.Error(),
+		})
+		return
+	}
+
+	err = utils.SetTokens(ctx, accessToken, refreshToken)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "login failed! " + err.Error(),
+		})
+		return
+	}
+
+	ctx.Redirect(http.StatusFound, redirectTo)
+}
+
+func (a Authenticator) generateTokens(ctx *gin.Context, userId int, provider string) (accessToken, refreshToken string, err error) {
+	roles, permissions, err := a.roleService.GetClaims(userId)
+	if err != nil {
+		return "", "", err
+	}
+
+	accessToken, err = middleware.GenerateJWT(userId, roles, permissions)
+	if err != nil {
+		return "", "", err
+	}
+
+	refreshToken, err = a.refreshService.Save(userId, refresh.NewMetadata(ctx, refresh.SocialLoginMethod(provider)))
+	if err != nil {
+		return "", "", err
+	}
+
+	return accessToken, refreshToken, nil
+}
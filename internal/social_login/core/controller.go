@@ -0,0 +1,228 @@
+package core
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/oauth2"
+	"net/http"
+	"social-media-application/internal/social_login/provider_type"
+	"social-media-application/internal/social_login/social_user"
+	"social-media-application/internal/user"
+	"social-media-application/middlewares"
+	"strings"
+)
+
+type Controller struct {
+	provider            Provider
+	socialUserService   social_user.Service
+	userService         user.Service
+	providerTypeService provider_type.Service
+	authenticator       *Authenticator
+}
+
+func NewController(provider Provider, socialUserService social_user.Service, userService user.Service, providerTypeService provider_type.Service, authenticator *Authenticator) *Controller {
+	return &Controller{
+		provider:            provider,
+		socialUserService:   socialUserService,
+		userService:         userService,
+		providerTypeService: providerTypeService,
+		authenticator:       authenticator,
+	}
+}
+
+func (c Controller) RegisterRoutes(e *gin.Engine) {
+	r := e.Group("/auth/" + strings.ToLower(c.provider.Name()))
+	{
+		r.GET("", c.login)
+		r.GET("/callback", c.callback)
+		r.GET("/link", middleware.JWT, c.link)
+	}
+}
+
+func (c Controller) login(ctx *gin.Context) {
+	config, err := c.provider.OAuth2Config(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, gin.H{
+			"message": "login failed! " + err.Error(),
+		})
+		return
+	}
+
+	// Redirect user to the provider login page
+	begin(ctx, config, c.provider.Name(), 0, c.provider.AuthCodeOptions()...)
+}
+
+// link starts the same flow as login but the callback adds the identity to the current user
+func (c Controller) link(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "link failed " + err.Error(),
+		})
+		return
+	}
+
+	config, err := c.provider.OAuth2Config(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, gin.H{
+			"message": "link failed " + err.Error(),
+		})
+		return
+	}
+
+	begin(ctx, config, c.provider.Name(), sub, c.provider.AuthCodeOptions()...)
+}
+
+func (c Controller) callback(ctx *gin.Context) {
+	// Verify the state to prevent login CSRF
+	s, err := verify(ctx, c.provider.Name())
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "authentication failed " + err.Error(),
+		})
+		return
+	}
+
+	// Extract the "code" query parameter from the URL.
+	code := ctx.Query("code")
+	if strings.TrimSpace(code) == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "missing code",
+		})
+		return
+	}
+
+	config, err := c.provider.OAuth2Config(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, gin.H{
+			"message": "authentication failed " + err.Error(),
+		})
+		return
+	}
+
+	// Exchange the authorization code for an OAuth2 token.
+	token, err := config.Exchange(ctx.Request.Context(), code, oauth2.VerifierOption(s.Verifier))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "authentication failed " + err.Error(),
+		})
+		return
+	}
+
+	userInfo, err := c.provider.GetUserInfo(ctx.Request.Context(), config, token)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "failed to get user information " + err.Error(),
+		})
+		return
+	}
+	// end of auth provider call
+
+	// Start of backend logic
+	if strings.TrimSpace(userInfo.Id) == "" || strings.TrimSpace(userInfo.Email) == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "provider did not return the user id or email",
+		})
+		return
+	}
+
+	// Get provider type if exists
+	providerType, err := c.providerTypeService.GetByName(c.provider.Name())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "failed to get provider type",
+		})
+		return
+	}
+
+	// Linking matches the provider id only so the provider email does not have to be verified
+	if s.LinkUserId > 0 {
+		c.linkIdentity(ctx, s.LinkUserId, providerType.Id, userInfo.Id, s.RedirectTo)
+		return
+	}
+
+	if !userInfo.IsEmailVerified {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "email not verified",
+		})
+		return
+	}
+
+	// 1 User already exists
+	socialUser, err := c.socialUserService.GetByProviderTypeAndId(providerType.Id, userInfo.Id)
+	if err == nil {
+		c.authenticator.Authenticate(ctx, socialUser.UserId, c.provider.Name(), s.RedirectTo)
+		return
+	}
+
+	// 2 User already exists with the same email, accounts are only linked by the logged-in user
+	// so an email at another provider cannot be used to take over an account
+	_, err = c.userService.GetByEmail(userInfo.Email)
+	if err == nil {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"message": "login failed! an account with this email already exists, login with it and link this account with /auth/" + strings.ToLower(c.provider.Name()) + "/link",
+		})
+		return
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "login failed! " + err.Error(),
+		})
+		return
+	}
+
+	// 3 User not exists and no links to other social account
+	id, err := c.userService.SaveSocial(userInfo.FirstName, userInfo.LastName, userInfo.Email)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "login failed! " + err.Error(),
+		})
+		return
+	}
+
+	_, err = c.socialUserService.Save(providerType.Id, int(id), userInfo.Id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "login failed! " + err.Error(),
+		})
+		return
+	}
+
+	c.authenticator.Authenticate(ctx, int(id), c.provider.Name(), s.RedirectTo)
+}
+
+func (c Controller) linkIdentity(ctx *gin.Context, userId, providerTypeId int, providerId, redirectTo string) {
+	socialUser, err := c.socialUserService.GetByProviderTypeAndId(providerTypeId, providerId)
+	if err == nil {
+		if socialUser.UserId != userId {
+			ctx.JSON(http.StatusConflict, gin.H{
+				"message": "link failed! account is already linked to another user",
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusConflict, gin.H{
+			"message": "link failed! account is already linked",
+		})
+		return
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "link failed! " + err.Error(),
+		})
+		return
+	}
+
+	_, err = c.socialUserService.Save(providerTypeId, userId, providerId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "link failed! " + err.Error(),
+		})
+		return
+	}
+
+	ctx.Redirect(http.StatusFound, redirectTo)
+}
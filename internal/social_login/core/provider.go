@@ -0,0 +1,55 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"golang.org/x/oauth2"
+	"io"
+	"net/http"
+)
+
+// UserInfo is the provider user mapped to the fields needed to login or register
+type UserInfo struct {
+	Id              string
+	Email           string
+	FirstName       string
+	LastName        string
+	IsEmailVerified bool
+}
+
+// Provider is implemented by every social login, the login and callback flow is shared in Controller
+type Provider interface {
+	// Name must match a row in provider_type, the routes are /auth/<name in lower case>
+	Name() string
+
+	// OAuth2Config may fetch the endpoints from the provider so it's resolved per request
+	OAuth2Config(ctx context.Context) (*oauth2.Config, error)
+
+	AuthCodeOptions() []oauth2.AuthCodeOption
+
+	GetUserInfo(ctx context.Context, config *oauth2.Config, token *oauth2.Token) (UserInfo, error)
+}
+
+// GetJSON decodes the response of a GET request, it's used for userinfo and discovery endpoints
+func GetJSON(client *http.Client, url string, v any) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			return
+		}
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber() // Keeps numeric ids as is instead of float64
+
+	return decoder.Decode(v)
+}
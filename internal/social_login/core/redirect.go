@@ -0,0 +1,48 @@
+package core
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// getRedirectTo returns FRONT_END_REDIRECT_URL when redirectTo is empty
+// otherwise redirectTo must be an absolute url whose origin is in FRONT_END_REDIRECT_ALLOW_LIST
+// so the login cannot be used as an open redirect
+func getRedirectTo(redirectTo string) (string, error) {
+	if strings.TrimSpace(redirectTo) == "" {
+		return os.Getenv("FRONT_END_REDIRECT_URL"), nil
+	}
+
+	u, err := url.Parse(redirectTo)
+	if err != nil || u.Scheme == "" || u.Host == "" || u.User != nil {
+		return "", errors.New("redirect_to must be an absolute url")
+	}
+
+	origin := strings.ToLower(u.Scheme + "://" + u.Host)
+	for _, allowed := range allowList() {
+		if origin == allowed {
+			return u.String(), nil
+		}
+	}
+
+	return "", errors.New("redirect_to is not allowed")
+}
+
+// allowList also includes the origin of FRONT_END_REDIRECT_URL
+func allowList() []string {
+	origins := make([]string, 0)
+	for _, allowed := range strings.Split(os.Getenv("FRONT_END_REDIRECT_ALLOW_LIST"), ",") {
+		if strings.TrimSpace(allowed) != "" {
+			origins = append(origins, strings.ToLower(strings.TrimSuffix(strings.TrimSpace(allowed), "/")))
+		}
+	}
+
+	u, err := url.Parse(os.Getenv("FRONT_END_REDIRECT_URL"))
+	if err == nil && u.Host != "" {
+		origins = append(origins, strings.ToLower(u.Scheme+"://"+u.Host))
+	}
+
+	return origins
+}
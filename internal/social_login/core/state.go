@@ -0,0 +1,156 @@
+package core
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/oauth2"
+	"net/http"
+	"os"
+	"social-media-application/utils"
+	"strings"
+	"time"
+)
+
+// stateExpiration is how long the user has to finish logging in with the provider
+const stateExpiration = 10 * time.Minute
+
+// state is stored in a signed cookie so nothing has to be saved in the database before the user logs in
+type state struct {
+	State      string `json:"state"`
+	Verifier   string `json:"verifier"`
+	Provider   string `json:"provider"`
+	RedirectTo string `json:"redirect_to"`
+	LinkUserId int    `json:"link_user_id,omitempty"`
+	ExpiresAt  int64  `json:"expires_at"`
+}
+
+// begin redirects the user to the provider with a random state and a PKCE challenge
+// the optional redirect_to query parameter is where the user goes after logging in
+// linkUserId is set when a logged-in user links the provider to their account
+func begin(ctx *gin.Context, config *oauth2.Config, provider string, linkUserId int, opts ...oauth2.AuthCodeOption) {
+	redirectTo, err := getRedirectTo(ctx.Query("redirect_to"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "login failed! " + err.Error(),
+		})
+		return
+	}
+
+	random, err := utils.GenerateToken(32)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "login failed! " + err.Error(),
+		})
+		return
+	}
+
+	s := state{
+		State:      random,
+		Verifier:   oauth2.GenerateVerifier(),
+		Provider:   provider,
+		RedirectTo: redirectTo,
+		LinkUserId: linkUserId,
+		ExpiresAt:  time.Now().Add(stateExpiration).Unix(),
+	}
+
+	value, err := sign(s)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "login failed! " + err.Error(),
+		})
+		return
+	}
+
+	utils.SetOAuthState(ctx, value, int(stateExpiration.Seconds()))
+
+	opts = append(opts, oauth2.S256ChallengeOption(s.Verifier))
+	ctx.Redirect(http.StatusTemporaryRedirect, config.AuthCodeURL(s.State, opts...))
+}
+
+// verify checks the state returned by the provider against the cookie set in begin
+// the cookie is always cleared so a state can only be used once
+func verify(ctx *gin.Context, provider string) (state, error) {
+	value, err := ctx.Cookie("oauthState")
+	utils.ClearOAuthState(ctx)
+	if err != nil {
+		return state{}, errors.New("missing state, please login again")
+	}
+
+	s, err := parse(value)
+	if err != nil {
+		return state{}, err
+	}
+
+	if time.Now().Unix() > s.ExpiresAt {
+		return state{}, errors.New("state expired, please login again")
+	}
+
+	if s.Provider != provider {
+		return state{}, errors.New("invalid state")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(s.State), []byte(ctx.Query("state"))) != 1 {
+		return state{}, errors.New("invalid state")
+	}
+
+	return s, nil
+}
+
+func sign(s state) (string, error) {
+	if len(getSecretKey()) == 0 {
+		return "", errors.New("OAUTH_STATE_SECRET_KEY is not set")
+	}
+
+	payload, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + signature(encoded), nil
+}
+
+func parse(value string) (state, error) {
+	if len(getSecretKey()) == 0 {
+		return state{}, errors.New("OAUTH_STATE_SECRET_KEY is not set")
+	}
+
+	encoded, sig, ok := strings.Cut(value, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(signature(encoded))) {
+		return state{}, errors.New("invalid state")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return state{}, errors.New("invalid state")
+	}
+
+	var s state
+	err = json.Unmarshal(payload, &s)
+	if err != nil {
+		return state{}, errors.New("invalid state")
+	}
+
+	return s, nil
+}
+
+func signature(encoded string) string {
+	mac := hmac.New(sha256.New, getSecretKey())
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// getSecretKey falls back to the old JWT_SECRET_KEY so existing deployments keep working
+func getSecretKey() []byte {
+	key := os.Getenv("OAUTH_STATE_SECRET_KEY")
+	if strings.TrimSpace(key) == "" {
+		return []byte(strings.TrimSpace(os.Getenv("JWT_SECRET_KEY")))
+	}
+
+	return []byte(key)
+}
@@ -0,0 +1,83 @@
+package oidc
+
+import (
+	"os"
+	"strings"
+)
+
+// ClaimMapping is the name of the userinfo claims for each field
+// empty EmailVerified means the provider only returns verified emails
+type ClaimMapping struct {
+	Id            string
+	Email         string
+	EmailVerified string
+	FirstName     string
+	LastName      string
+	Name          string // Split into first and last name when the provider has no separate claims
+}
+
+// Config endpoints are discovered from the issuer, AuthUrl, TokenUrl, and UserInfoUrl are only set
+// for OAuth2 providers without discovery like GitHub
+type Config struct {
+	Name         string
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	RedirectUrl  string
+	Scopes       []string
+
+	AuthUrl     string
+	TokenUrl    string
+	UserInfoUrl string
+
+	Claims ClaimMapping
+}
+
+// ConfigsFromEnv reads every provider in OIDC_PROVIDERS from the OIDC_<NAME>_* variables
+func ConfigsFromEnv() []Config {
+	configs := make([]Config, 0)
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		env := func(key, defaultValue string) string {
+			value, ok := os.LookupEnv("OIDC_" + name + "_" + key)
+			if !ok {
+				return defaultValue
+			}
+
+			return strings.TrimSpace(value)
+		}
+
+		scopes := make([]string, 0)
+		for _, scope := range strings.Split(env("SCOPES", "openid,email,profile"), ",") {
+			if strings.TrimSpace(scope) != "" {
+				scopes = append(scopes, strings.TrimSpace(scope))
+			}
+		}
+
+		configs = append(configs, Config{
+			Name:         name,
+			Issuer:       env("ISSUER", ""),
+			ClientId:     env("CLIENT_ID", ""),
+			ClientSecret: env("CLIENT_SECRET", ""),
+			RedirectUrl:  env("REDIRECT_URL", ""),
+			Scopes:       scopes,
+			AuthUrl:      env("AUTH_URL", ""),
+			TokenUrl:     env("TOKEN_URL", ""),
+			UserInfoUrl:  env("USERINFO_URL", ""),
+			Claims: ClaimMapping{
+				Id:            env("CLAIM_ID", "sub"),
+				Email:         env("CLAIM_EMAIL", "email"),
+				EmailVerified: env("CLAIM_EMAIL_VERIFIED", "email_verified"),
+				FirstName:     env("CLAIM_FIRST_NAME", "given_name"),
+				LastName:      env("CLAIM_LAST_NAME", "family_name"),
+				Name:          env("CLAIM_NAME", "name"),
+			},
+		})
+	}
+
+	return configs
+}
@@ -0,0 +1,185 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"golang.org/x/oauth2"
+	"net/http"
+	"social-media-application/internal/social_login/core"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Provider works with any OpenID Connect provider like Keycloak or an internal IdP
+// the user is read from the userinfo endpoint over TLS with the access token so the id token is not needed
+type Provider struct {
+	config Config
+	client *http.Client
+
+	mu           sync.Mutex
+	oauth2Config *oauth2.Config
+	userInfoUrl  string
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+func NewProvider(config Config) *Provider {
+	return &Provider{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *Provider) Name() string {
+	return p.config.Name
+}
+
+// OAuth2Config discovers the endpoints on first use and keeps them once it succeeds
+// so the application still starts when the provider is down
+func (p *Provider) OAuth2Config(ctx context.Context) (*oauth2.Config, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.oauth2Config != nil {
+		return p.oauth2Config, nil
+	}
+
+	endpoints := discovery{
+		AuthorizationEndpoint: p.config.AuthUrl,
+		TokenEndpoint:         p.config.TokenUrl,
+		UserInfoEndpoint:      p.config.UserInfoUrl,
+	}
+
+	if endpoints.AuthorizationEndpoint == "" || endpoints.TokenEndpoint == "" || endpoints.UserInfoEndpoint == "" {
+		if strings.TrimSpace(p.config.Issuer) == "" {
+			return nil, errors.New("issuer is required when endpoints are not configured")
+		}
+
+		var discovered discovery
+		err := core.GetJSON(p.client, strings.TrimSuffix(p.config.Issuer, "/")+"/.well-known/openid-configuration", &discovered)
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.TrimSuffix(discovered.Issuer, "/") != strings.TrimSuffix(p.config.Issuer, "/") {
+			return nil, errors.New("discovered issuer does not match the configured issuer")
+		}
+
+		// Configured endpoints take precedence over discovered ones
+		if endpoints.AuthorizationEndpoint == "" {
+			endpoints.AuthorizationEndpoint = discovered.AuthorizationEndpoint
+		}
+
+		if endpoints.TokenEndpoint == "" {
+			endpoints.TokenEndpoint = discovered.TokenEndpoint
+		}
+
+		if endpoints.UserInfoEndpoint == "" {
+			endpoints.UserInfoEndpoint = discovered.UserInfoEndpoint
+		}
+	}
+
+	if endpoints.AuthorizationEndpoint == "" || endpoints.TokenEndpoint == "" || endpoints.UserInfoEndpoint == "" {
+		return nil, errors.New("provider has no authorization, token, or userinfo endpoint")
+	}
+
+	p.oauth2Config = &oauth2.Config{
+		ClientID:     p.config.ClientId,
+		ClientSecret: p.config.ClientSecret,
+		RedirectURL:  p.config.RedirectUrl,
+		Scopes:       p.config.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  endpoints.AuthorizationEndpoint,
+			TokenURL: endpoints.TokenEndpoint,
+		},
+	}
+	p.userInfoUrl = endpoints.UserInfoEndpoint
+
+	return p.oauth2Config, nil
+}
+
+func (p *Provider) AuthCodeOptions() []oauth2.AuthCodeOption {
+	return []oauth2.AuthCodeOption{
+		oauth2.SetAuthURLParam("prompt", "login"),
+	}
+}
+
+func (p *Provider) GetUserInfo(ctx context.Context, config *oauth2.Config, token *oauth2.Token) (core.UserInfo, error) {
+	p.mu.Lock()
+	userInfoUrl := p.userInfoUrl
+	p.mu.Unlock()
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.client)
+
+	claims := make(map[string]any)
+	err := core.GetJSON(config.Client(ctx, token), userInfoUrl, &claims)
+	if err != nil {
+		return core.UserInfo{}, err
+	}
+
+	mapping := p.config.Claims
+	userInfo := core.UserInfo{
+		Id:              claimString(claims, mapping.Id),
+		Email:           claimString(claims, mapping.Email),
+		FirstName:       claimString(claims, mapping.FirstName),
+		LastName:        claimString(claims, mapping.LastName),
+		IsEmailVerified: mapping.EmailVerified == "" || claimBool(claims, mapping.EmailVerified),
+	}
+
+	if userInfo.FirstName == "" || userInfo.LastName == "" {
+		firstName, lastName, _ := strings.Cut(strings.TrimSpace(claimString(claims, mapping.Name)), " ")
+		if userInfo.FirstName == "" {
+			userInfo.FirstName = firstName
+		}
+
+		if userInfo.LastName == "" {
+			userInfo.LastName = strings.TrimSpace(lastName)
+		}
+	}
+
+	// Mononymous users
+	if userInfo.LastName == "" {
+		userInfo.LastName = userInfo.FirstName
+	}
+
+	return userInfo, nil
+}
+
+// claimString also accepts numbers since some providers like GitHub use numeric ids
+func claimString(claims map[string]any, name string) string {
+	if name == "" {
+		return ""
+	}
+
+	switch value := claims[name].(type) {
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	case bool:
+		return strconv.FormatBool(value)
+	default:
+		return ""
+	}
+}
+
+// claimBool also accepts "true" since some providers return email_verified as string
+func claimBool(claims map[string]any, name string) bool {
+	switch value := claims[name].(type) {
+	case bool:
+		return value
+	case string:
+		isTrue, err := strconv.ParseBool(value)
+		return err == nil && isTrue
+	default:
+		return false
+	}
+}
@@ -0,0 +1,437 @@
+package oidc_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"social-media-application/internal/refresh"
+	"social-media-application/internal/role"
+	"social-media-application/internal/social_login/core"
+	"social-media-application/internal/social_login/provider/oidc"
+	"social-media-application/internal/social_login/provider/oidc/oidctest"
+	"social-media-application/internal/social_login/provider_type"
+	"social-media-application/internal/social_login/social_user"
+	"social-media-application/internal/totp"
+	"social-media-application/internal/user"
+	middleware "social-media-application/middlewares"
+)
+
+const frontEnd = "https://front.test/home"
+
+// The fakes embed the interfaces so only the methods used by the login are implemented
+type (
+	fakeUserService struct {
+		user.Service
+
+		mu    sync.Mutex
+		saved []user.User
+	}
+
+	fakeSocialUserService struct {
+		social_user.Service
+
+		mu    sync.Mutex
+		saved map[string]int // user id by provider id
+	}
+
+	fakeProviderTypeService struct {
+		provider_type.Service
+	}
+
+	fakeRefreshService struct {
+		refresh.Service
+	}
+
+	fakeRoleService struct {
+		role.Service
+	}
+
+	fakeTotpService struct {
+		totp.Service
+	}
+)
+
+func (s *fakeUserService) GetByEmail(email string) (user.User, error) {
+	return user.User{}, sql.ErrNoRows
+}
+
+func (s *fakeUserService) SaveSocial(firstName, lastName, email string) (id int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.saved = append(s.saved, user.User{FirstName: firstName, LastName: lastName, Email: email})
+	return int64(len(s.saved)), nil
+}
+
+func (s *fakeSocialUserService) GetByProviderTypeAndId(providerTypeId int, providerId string) (social_user.Social, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userId, ok := s.saved[providerId]
+	if !ok {
+		return social_user.Social{}, sql.ErrNoRows
+	}
+
+	return social_user.Social{UserId: userId}, nil
+}
+
+func (s *fakeSocialUserService) Save(providerTypeId, userId int, providerId string) (id int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.saved[providerId] = userId
+	return int64(len(s.saved)), nil
+}
+
+func (s fakeProviderTypeService) GetByName(name string) (provider_type.ProviderType, error) {
+	return provider_type.ProviderType{Id: 1, Name: name}, nil
+}
+
+func (s fakeRefreshService) Save(userId int, metadata refresh.Metadata) (token string, err error) {
+	return "refresh-token", nil
+}
+
+func (s fakeRoleService) GetClaims(userId int) (roles []string, permissions []string, err error) {
+	return []string{"USER"}, nil, nil
+}
+
+func (s fakeTotpService) IsEnabled(userId int) (bool, error) {
+	return false, nil
+}
+
+type testApi struct {
+	server            *httptest.Server
+	userService       *fakeUserService
+	socialUserService *fakeSocialUserService
+}
+
+// newTestApi runs core.Controller with the oidc provider against the mock provider
+// the api uses TLS since the cookies are secure
+func newTestApi(t *testing.T, idp *httptest.Server, claims oidc.ClaimMapping) *testApi {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	t.Setenv("OAUTH_STATE_SECRET_KEY", "test-secret")
+	t.Setenv("FRONT_END_REDIRECT_URL", frontEnd)
+	t.Setenv("JWT_ISSUER", "test-issuer")
+	t.Setenv("JWT_AUDIENCE", "test-audience")
+	t.Setenv("JWT_EXPIRATION_IN_MINUTE", "15")
+	t.Setenv("REFRESH_TOKEN_EXPIRATION_IN_DAYS", "7")
+	t.Setenv("JWT_PRIVATE_KEY_PATH", "")
+
+	err := middleware.InitKeyring()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := gin.New()
+	server := httptest.NewTLSServer(e)
+	t.Cleanup(server.Close)
+
+	provider := oidc.NewProvider(oidc.Config{
+		Name:         "MOCK",
+		Issuer:       idp.URL,
+		ClientId:     "mock-client",
+		ClientSecret: "mock-secret",
+		RedirectUrl:  server.URL + "/auth/mock/callback",
+		Scopes:       []string{"openid", "email", "profile"},
+		Claims:       claims,
+	})
+
+	api := &testApi{
+		server:            server,
+		userService:       &fakeUserService{},
+		socialUserService: &fakeSocialUserService{saved: make(map[string]int)},
+	}
+
+	authenticator := core.NewAuthenticator(fakeRefreshService{}, fakeRoleService{}, fakeTotpService{})
+	core.NewController(provider, api.socialUserService, api.userService, fakeProviderTypeService{}, authenticator).RegisterRoutes(e)
+
+	return api
+}
+
+// client follows the redirects between the api and the provider and stops at the front end
+func (api *testApi) client(t *testing.T) *http.Client {
+	t.Helper()
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := api.server.Client()
+	client.Jar = jar
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !strings.HasPrefix(req.URL.String(), api.server.URL) && !strings.HasSuffix(req.URL.Path, "/authorize") {
+			return http.ErrUseLastResponse
+		}
+
+		return nil
+	}
+
+	return client
+}
+
+func defaultClaims() oidc.ClaimMapping {
+	return oidc.ClaimMapping{
+		Id:            "sub",
+		Email:         "email",
+		EmailVerified: "email_verified",
+		FirstName:     "given_name",
+		LastName:      "family_name",
+		Name:          "name",
+	}
+}
+
+func TestProviderDiscoversEndpoints(t *testing.T) {
+	idp := oidctest.NewServer(oidctest.User{})
+	defer idp.Close()
+
+	provider := oidc.NewProvider(oidc.Config{Name: "MOCK", Issuer: idp.URL + "/"})
+	config, err := provider.OAuth2Config(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Endpoint.AuthURL != idp.URL+"/authorize" || config.Endpoint.TokenURL != idp.URL+"/token" {
+		t.Fatalf("endpoints are %+v, want the ones of %s", config.Endpoint, idp.URL)
+	}
+
+	other := oidc.NewProvider(oidc.Config{Name: "MOCK", Issuer: idp.URL + "/realms/other"})
+	_, err = other.OAuth2Config(context.Background())
+	if err == nil {
+		t.Fatal("discovery should fail when the issuer does not match")
+	}
+}
+
+func TestLoginRegistersTheProviderUser(t *testing.T) {
+	idp := oidctest.NewServer(oidctest.User{
+		Sub:           "mock-user-1",
+		Email:         "ada@example.com",
+		EmailVerified: true,
+		GivenName:     "Ada",
+		FamilyName:    "Lovelace",
+	})
+	defer idp.Close()
+
+	api := newTestApi(t, idp, defaultClaims())
+	client := api.client(t)
+
+	resp, err := client.Get(api.server.URL + "/auth/mock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != frontEnd {
+		t.Fatalf("login ended with %d to %q, want a redirect to the front end", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	if len(api.userService.saved) != 1 {
+		t.Fatalf("saved users are %+v, want the provider user", api.userService.saved)
+	}
+
+	saved := api.userService.saved[0]
+	if saved.FirstName != "Ada" || saved.LastName != "Lovelace" || saved.Email != "ada@example.com" {
+		t.Fatalf("saved user is %+v, want the userinfo claims", saved)
+	}
+
+	if _, ok := api.socialUserService.saved["mock-user-1"]; !ok {
+		t.Fatal("the provider id should be linked to the new user")
+	}
+
+	cookies := client.Jar.Cookies(mustParse(t, api.server.URL+"/"))
+	if !hasCookie(cookies, "accessToken") {
+		t.Fatalf("cookies are %v, want the access token", cookies)
+	}
+}
+
+func TestUserInfoClaimMapping(t *testing.T) {
+	tests := []struct {
+		name       string
+		user       oidctest.User
+		claims     oidc.ClaimMapping
+		wantStatus int
+		wantFirst  string
+		wantLast   string
+	}{
+		{
+			name:       "name is split when there are no separate claims",
+			user:       oidctest.User{Sub: "1", Email: "grace@example.com", EmailVerified: true, Name: "Grace Brewster Hopper"},
+			claims:     defaultClaims(),
+			wantStatus: http.StatusFound,
+			wantFirst:  "Grace",
+			wantLast:   "Brewster Hopper",
+		},
+		{
+			name:       "mononymous users use the name as both",
+			user:       oidctest.User{Sub: "2", Email: "plato@example.com", EmailVerified: true, Name: "Plato"},
+			claims:     defaultClaims(),
+			wantStatus: http.StatusFound,
+			wantFirst:  "Plato",
+			wantLast:   "Plato",
+		},
+		{
+			name:       "unverified emails are rejected",
+			user:       oidctest.User{Sub: "3", Email: "eve@example.com", GivenName: "Eve", FamilyName: "Doe"},
+			claims:     defaultClaims(),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "providers that only return verified emails have no verified claim",
+			user: oidctest.User{Sub: "4", Email: "alan@example.com", GivenName: "Alan", FamilyName: "Turing"},
+			claims: func() oidc.ClaimMapping {
+				claims := defaultClaims()
+				claims.EmailVerified = ""
+				return claims
+			}(),
+			wantStatus: http.StatusFound,
+			wantFirst:  "Alan",
+			wantLast:   "Turing",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			idp := oidctest.NewServer(test.user)
+			defer idp.Close()
+
+			api := newTestApi(t, idp, test.claims)
+			resp, err := api.client(t).Get(api.server.URL + "/auth/mock")
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = resp.Body.Close()
+
+			if resp.StatusCode != test.wantStatus {
+				t.Fatalf("status is %d, want %d", resp.StatusCode, test.wantStatus)
+			}
+
+			if test.wantStatus != http.StatusFound {
+				if len(api.userService.saved) != 0 {
+					t.Fatalf("saved users are %+v, want none", api.userService.saved)
+				}
+				return
+			}
+
+			saved := api.userService.saved[0]
+			if saved.FirstName != test.wantFirst || saved.LastName != test.wantLast {
+				t.Fatalf("saved name is %q %q, want %q %q", saved.FirstName, saved.LastName, test.wantFirst, test.wantLast)
+			}
+		})
+	}
+}
+
+func TestCallbackRejectsStateMismatch(t *testing.T) {
+	idp := oidctest.NewServer(oidctest.User{Sub: "1", Email: "ada@example.com", EmailVerified: true, Name: "Ada Lovelace"})
+	defer idp.Close()
+
+	api := newTestApi(t, idp, defaultClaims())
+	client := api.client(t)
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	code, _ := authorize(t, client, api.server.URL+"/auth/mock")
+
+	resp, err := client.Get(api.server.URL + "/auth/mock/callback?code=" + url.QueryEscape(code) + "&state=forged")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status is %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	if len(api.userService.saved) != 0 {
+		t.Fatal("no user should be saved with an invalid state")
+	}
+}
+
+// A code intercepted from one login cannot be redeemed with the state and verifier of another
+func TestCallbackRejectsCodeOfAnotherVerifier(t *testing.T) {
+	idp := oidctest.NewServer(oidctest.User{Sub: "1", Email: "ada@example.com", EmailVerified: true, Name: "Ada Lovelace"})
+	defer idp.Close()
+
+	api := newTestApi(t, idp, defaultClaims())
+	noFollow := func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	victim := api.client(t)
+	victim.CheckRedirect = noFollow
+	code, _ := authorize(t, victim, api.server.URL+"/auth/mock")
+
+	attacker := api.client(t)
+	attacker.CheckRedirect = noFollow
+	_, state := authorize(t, attacker, api.server.URL+"/auth/mock")
+
+	resp, err := attacker.Get(api.server.URL + "/auth/mock/callback?code=" + url.QueryEscape(code) + "&state=" + url.QueryEscape(state))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status is %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	if len(api.userService.saved) != 0 {
+		t.Fatal("no user should be saved when the verifier does not match")
+	}
+}
+
+// authorize starts the login and returns the code and state the provider sends back to the callback
+func authorize(t *testing.T, client *http.Client, loginUrl string) (code, state string) {
+	t.Helper()
+
+	resp, err := client.Get(loginUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	authorizeUrl := resp.Header.Get("Location")
+	if !strings.Contains(authorizeUrl, "code_challenge=") {
+		t.Fatalf("authorize url %q should have a PKCE challenge", authorizeUrl)
+	}
+
+	resp, err = client.Get(authorizeUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	callback := mustParse(t, resp.Header.Get("Location"))
+	return callback.Query().Get("code"), callback.Query().Get("state")
+}
+
+func mustParse(t *testing.T, rawUrl string) *url.URL {
+	t.Helper()
+
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return u
+}
+
+func hasCookie(cookies []*http.Cookie, name string) bool {
+	for _, cookie := range cookies {
+		if cookie.Name == name && cookie.Value != "" {
+			return true
+		}
+	}
+
+	return false
+}
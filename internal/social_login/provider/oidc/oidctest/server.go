@@ -0,0 +1,172 @@
+// Package oidctest is a mock OpenID Connect provider for tests and local development
+// every authorization request is approved right away as the configured user
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+type User struct {
+	Sub           string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
+	Name          string `json:"name"`
+}
+
+type authorization struct {
+	redirectUri   string
+	codeChallenge string
+}
+
+type handler struct {
+	user User
+
+	mu             sync.Mutex
+	authorizations map[string]authorization // by code
+	accessTokens   map[string]bool
+}
+
+// NewServer starts the mock provider, the issuer is the URL of the returned server
+func NewServer(user User) *httptest.Server {
+	return httptest.NewServer(NewHandler(user))
+}
+
+func NewHandler(user User) http.Handler {
+	h := &handler{
+		user:           user,
+		authorizations: make(map[string]authorization),
+		accessTokens:   make(map[string]bool),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", h.discovery)
+	mux.HandleFunc("GET /authorize", h.authorize)
+	mux.HandleFunc("POST /token", h.token)
+	mux.HandleFunc("GET /userinfo", h.userInfo)
+	return mux
+}
+
+func (h *handler) discovery(w http.ResponseWriter, r *http.Request) {
+	issuer := issuerOf(r)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                           issuer,
+		"authorization_endpoint":           issuer + "/authorize",
+		"token_endpoint":                   issuer + "/token",
+		"userinfo_endpoint":                issuer + "/userinfo",
+		"response_types_supported":         []string{"code"},
+		"code_challenge_methods_supported": []string{"S256"},
+	})
+}
+
+func (h *handler) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectUri, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || redirectUri.Scheme == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	if query.Get("code_challenge") != "" && query.Get("code_challenge_method") != "S256" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	code := random()
+	h.mu.Lock()
+	h.authorizations[code] = authorization{
+		redirectUri:   redirectUri.String(),
+		codeChallenge: query.Get("code_challenge"),
+	}
+	h.mu.Unlock()
+
+	callback := redirectUri.Query()
+	callback.Set("code", code)
+	callback.Set("state", query.Get("state"))
+	redirectUri.RawQuery = callback.Encode()
+
+	http.Redirect(w, r, redirectUri.String(), http.StatusFound)
+}
+
+func (h *handler) token(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	h.mu.Lock()
+	auth, ok := h.authorizations[code]
+	delete(h.authorizations, code) // Codes can only be used once
+	h.mu.Unlock()
+
+	if !ok || auth.redirectUri != r.PostForm.Get("redirect_uri") {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	if auth.codeChallenge != "" {
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if base64.RawURLEncoding.EncodeToString(sum[:]) != auth.codeChallenge {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+	}
+
+	accessToken := random()
+	h.mu.Lock()
+	h.accessTokens[accessToken] = true
+	h.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+	})
+}
+
+func (h *handler) userInfo(w http.ResponseWriter, r *http.Request) {
+	accessToken, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	h.mu.Lock()
+	isValid := ok && h.accessTokens[accessToken]
+	h.mu.Unlock()
+
+	if !isValid {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_token"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.user)
+}
+
+func issuerOf(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host
+}
+
+func random() string {
+	bytes := make([]byte, 24)
+	_, _ = rand.Read(bytes)
+	return base64.RawURLEncoding.EncodeToString(bytes)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,93 @@
+package social_user
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"social-media-application/middlewares"
+	"strconv"
+)
+
+type (
+	Controller interface {
+		getAll(ctx *gin.Context)
+
+		delete(ctx *gin.Context)
+
+		RegisterRoutes(e *gin.Engine)
+	}
+
+	ControllerImpl struct {
+		service Service
+	}
+)
+
+func NewController(service Service) Controller {
+	return &ControllerImpl{
+		service: service,
+	}
+}
+
+func (c ControllerImpl) RegisterRoutes(e *gin.Engine) {
+	// Linking a new identity starts at /auth/<provider>/link
+	r := e.Group("/users/identities", middleware.JWT)
+	{
+		r.GET("", c.getAll)
+		r.DELETE("/:id", c.delete)
+	}
+}
+
+func (c ControllerImpl) getAll(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all failed " + err.Error(),
+		})
+		return
+	}
+
+	identities, err := c.service.getAllBy(sub)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "get all failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, identities)
+}
+
+func (c ControllerImpl) delete(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "delete failed " + err.Error(),
+		})
+		return
+	}
+
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "delete failed " + err.Error(),
+		})
+		return
+	}
+
+	_, err = c.service.delete(sub, id)
+	if err != nil {
+		if errors.Is(err, ErrLastLoginMethod) {
+			ctx.JSON(http.StatusConflict, gin.H{
+				"message": "delete failed " + err.Error(),
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "delete failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
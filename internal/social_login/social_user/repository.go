@@ -1,12 +1,21 @@
 package social_user
 
-import "github.com/jmoiron/sqlx"
+import (
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"social-media-application/internal/user"
+)
+
+var ErrLastLoginMethod = errors.New("cannot unlink the last login method, set a password or add a passkey first")
 
 type (
 	Repository interface {
 		save(providerTypeId, userId int, providerId string) (id int64, err error)
 		findByProviderTypeAndId(providerTypeId int, providerId string) (Social, error)
 		isAlreadyExists(providerTypeId int, providerId string) (bool, error)
+
+		findAllBy(userId int) ([]Identity, error)
+		delete(userId, id int) (affectedRows int64, err error)
 	}
 
 	RepositoryImpl struct {
@@ -57,3 +66,57 @@ func (r RepositoryImpl) isAlreadyExists(providerTypeId int, providerId string) (
 
 	return exists, nil
 }
+
+func (r RepositoryImpl) findAllBy(userId int) ([]Identity, error) {
+	identities := make([]Identity, 0)
+	err := r.Select(&identities, `
+		SELECT us.id, us.created_at, us.provider_id, pt.name AS provider_type
+		FROM user_social us
+		JOIN provider_type pt ON pt.id = us.provider_type_id
+		WHERE us.user_id = ?
+		ORDER BY us.created_at`, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+func (r RepositoryImpl) delete(userId, id int) (affectedRows int64, err error) {
+	tx, err := r.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer func(tx *sqlx.Tx) {
+		err := tx.Rollback()
+		if err != nil {
+			return
+		}
+	}(tx)
+
+	loginMethods, err := user.LockAndCountLoginMethods(tx, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	if loginMethods <= 1 {
+		return 0, ErrLastLoginMethod
+	}
+
+	result, err := tx.Exec("DELETE FROM user_social WHERE id = ? AND user_id = ?", id, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
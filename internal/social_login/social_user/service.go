@@ -10,6 +10,9 @@ type (
 		Save(providerTypeId, userId int, providerId string) (id int64, err error)
 		GetByProviderTypeAndId(providerTypeId int, providerId string) (Social, error)
 		IsAlreadyExists(providerTypeId int, providerId string) (bool, error)
+
+		getAllBy(userId int) ([]Identity, error)
+		delete(userId, id int) (affectedRows int64, err error)
 	}
 
 	ServiceImpl struct {
@@ -77,3 +80,37 @@ func (s ServiceImpl) IsAlreadyExists(providerTypeId int, providerId string) (boo
 
 	return exists, nil
 }
+
+func (s ServiceImpl) getAllBy(userId int) ([]Identity, error) {
+	if userId <= 0 {
+		return nil, errors.New("user id is required")
+	}
+
+	identities, err := s.repository.findAllBy(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+func (s ServiceImpl) delete(userId, id int) (affectedRows int64, err error) {
+	if userId <= 0 {
+		return 0, errors.New("user id is required")
+	}
+
+	if id <= 0 {
+		return 0, errors.New("id is required")
+	}
+
+	affectedRows, err = s.repository.delete(userId, id)
+	if err != nil {
+		return 0, err
+	}
+
+	if affectedRows <= 0 {
+		return 0, errors.New("no rows affected")
+	}
+
+	return affectedRows, nil
+}
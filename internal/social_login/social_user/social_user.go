@@ -9,3 +9,11 @@ type Social struct {
 	UserId         int       `json:"user_id" db:"user_id"`
 	ProviderTypeId int       `json:"provider_type_id" db:"provider_type_id"`
 }
+
+// Identity is a linked social account shown in the user settings
+type Identity struct {
+	Id           int       `json:"id" db:"id"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	ProviderId   string    `json:"provider_id" db:"provider_id"`
+	ProviderType string    `json:"provider_type" db:"provider_type"`
+}
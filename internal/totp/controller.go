@@ -0,0 +1,291 @@
+package totp
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"social-media-application/internal/audit"
+	"social-media-application/internal/refresh"
+	"social-media-application/internal/role"
+	"social-media-application/middlewares"
+	"social-media-application/utils"
+	"strings"
+)
+
+type (
+	Controller interface {
+		enroll(ctx *gin.Context)
+		enable(ctx *gin.Context)
+		disable(ctx *gin.Context)
+
+		regenerateRecoveryCodes(ctx *gin.Context)
+
+		getStatus(ctx *gin.Context)
+
+		verify(ctx *gin.Context)
+
+		RegisterRoutes(e *gin.Engine)
+	}
+
+	ControllerImpl struct {
+		service        Service
+		refreshService refresh.Service
+		roleService    role.Service
+	}
+)
+
+func NewController(service Service, refreshService refresh.Service, roleService role.Service) Controller {
+	return &ControllerImpl{
+		service:        service,
+		refreshService: refreshService,
+		roleService:    roleService,
+	}
+}
+
+func (c ControllerImpl) RegisterRoutes(e *gin.Engine) {
+	r := e.Group("/users/totp")
+	{
+		// Public, second step of the login
+		r.POST("/verify", c.verify)
+
+		// Protected
+		r.GET("", middleware.JWT, c.getStatus)
+
+		r.POST("/enroll", middleware.JWT, c.enroll)
+		r.POST("/enable", middleware.JWT, c.enable)
+		r.POST("/disable", middleware.JWT, c.disable)
+		r.POST("/recovery-codes", middleware.JWT, c.regenerateRecoveryCodes)
+	}
+}
+
+func (c ControllerImpl) enroll(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "enroll failed " + err.Error(),
+		})
+		return
+	}
+
+	secret, uri, err := c.service.enroll(sub)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "enroll failed " + err.Error(),
+		})
+		return
+	}
+
+	// qr_payload is the text to be encoded as QR code by the front end
+	ctx.JSON(http.StatusOK, gin.H{
+		"secret":     secret,
+		"uri":        uri,
+		"qr_payload": uri,
+	})
+}
+
+func (c ControllerImpl) enable(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "enable failed " + err.Error(),
+		})
+		return
+	}
+
+	request := struct {
+		Code string `json:"code" binding:"required"`
+	}{}
+
+	if err := ctx.ShouldBind(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "enable failed " + err.Error(),
+		})
+		return
+	}
+
+	recoveryCodes, err := c.service.enable(sub, request.Code)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "enable failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+func (c ControllerImpl) disable(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "disable failed " + err.Error(),
+		})
+		return
+	}
+
+	request := struct {
+		Code string `json:"code" binding:"required"`
+	}{}
+
+	if err := ctx.ShouldBind(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "disable failed " + err.Error(),
+		})
+		return
+	}
+
+	_, err = c.service.disable(sub, request.Code)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "disable failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+func (c ControllerImpl) regenerateRecoveryCodes(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "regenerate recovery codes failed " + err.Error(),
+		})
+		return
+	}
+
+	request := struct {
+		Code string `json:"code" binding:"required"`
+	}{}
+
+	if err := ctx.ShouldBind(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "regenerate recovery codes failed " + err.Error(),
+		})
+		return
+	}
+
+	recoveryCodes, err := c.service.regenerateRecoveryCodes(sub, request.Code)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "regenerate recovery codes failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+func (c ControllerImpl) getStatus(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get status failed " + err.Error(),
+		})
+		return
+	}
+
+	status, err := c.service.getStatus(sub)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "get status failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, status)
+}
+
+// verify completes the login, the challenge comes from the body for local login
+// and from the challenge cookie for social login
+func (c ControllerImpl) verify(ctx *gin.Context) {
+	request := struct {
+		Challenge string `json:"challenge"`
+		Code      string `json:"code" binding:"required"`
+	}{}
+
+	if err := ctx.ShouldBind(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "verify failed " + err.Error(),
+		})
+		return
+	}
+
+	challenge := request.Challenge
+	if strings.TrimSpace(challenge) == "" {
+		cookie, err := ctx.Cookie("challenge")
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"message": "verify failed challenge is required",
+			})
+			return
+		}
+
+		challenge = cookie
+	}
+
+	parsedChallenge, err := middleware.ParseChallenge(challenge)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "verify failed " + err.Error(),
+		})
+		return
+	}
+
+	userId := parsedChallenge.UserId
+	err = c.service.verifyChallenge(userId, parsedChallenge.IssuedAt, request.Code)
+	if err != nil {
+		// The challenge cannot be used anymore once the user is locked
+		if errors.Is(err, ErrTooManyAttempts) {
+			c.service.loginFailed(userId, parsedChallenge.LoginMethod, "too_many_attempts", audit.NewOrigin(ctx))
+			utils.ClearChallenge(ctx)
+			ctx.JSON(http.StatusTooManyRequests, gin.H{
+				"message": "verify failed " + err.Error(),
+			})
+			return
+		}
+
+		c.service.loginFailed(userId, parsedChallenge.LoginMethod, "invalid_two_factor_code", audit.NewOrigin(ctx))
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "verify failed " + err.Error(),
+		})
+		return
+	}
+
+	roles, permissions, err := c.roleService.GetClaims(userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "verify failed " + err.Error(),
+		})
+		return
+	}
+
+	accessToken, err := middleware.GenerateJWT(userId, roles, permissions)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "verify failed " + err.Error(),
+		})
+		return
+	}
+
+	refreshToken, err := c.refreshService.Save(userId, refresh.NewMetadata(ctx, parsedChallenge.LoginMethod))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "verify failed " + err.Error(),
+		})
+		return
+	}
+
+	utils.ClearChallenge(ctx)
+	err = utils.SendTokens(ctx, accessToken, refreshToken, "authentication successful")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "verify failed " + err.Error(),
+		})
+		return
+	}
+}
@@ -0,0 +1,323 @@
+package totp
+
+import (
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type (
+	Repository interface {
+		save(userId int, secret string) (affectedRows int64, err error)
+
+		findBy(userId int) (Totp, error)
+		findEmail(userId int) (string, error)
+
+		countRecoveryCodes(userId int) (total int, err error)
+
+		enable(userId int, step int64, codeHashes []string) error
+		useStep(userId int, step int64) (affectedRows int64, err error)
+		useRecoveryCode(userId int, codeHash string) (affectedRows int64, err error)
+		replaceRecoveryCodes(userId int, codeHashes []string) error
+
+		reserveAttempt(userId int) (Totp, error)
+		resetAttempts(userId int) error
+		lock(userId int, until time.Time) error
+		useChallenge(userId int, issuedAt time.Time) (affectedRows int64, err error)
+
+		delete(userId int) (affectedRows int64, err error)
+	}
+
+	RepositoryImpl struct {
+		*sqlx.DB
+	}
+)
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &RepositoryImpl{
+		DB: db,
+	}
+}
+
+// save replaces a pending enrollment so only the latest secret can be enabled
+func (repository RepositoryImpl) save(userId int, secret string) (affectedRows int64, err error) {
+	result, err := repository.NamedExec(`
+		INSERT INTO totp (secret, user_id)
+		VALUES (:secret, :userId)
+		ON DUPLICATE KEY UPDATE
+			created_at = IF(enabled_at IS NULL, NOW(), created_at),
+			secret = IF(enabled_at IS NULL, VALUES(secret), secret),
+			last_used_step = IF(enabled_at IS NULL, 0, last_used_step)
+	`, map[string]any{
+		"secret": secret,
+		"userId": userId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
+func (repository RepositoryImpl) findBy(userId int) (Totp, error) {
+	var totp Totp
+	err := repository.Get(&totp, "SELECT * FROM totp WHERE user_id = ?", userId)
+	if err != nil {
+		return Totp{}, err
+	}
+
+	return totp, nil
+}
+
+// findEmail is used as the account name in authenticator apps
+func (repository RepositoryImpl) findEmail(userId int) (string, error) {
+	var email string
+	err := repository.Get(&email, "SELECT email FROM user WHERE id = ?", userId)
+	if err != nil {
+		return "", err
+	}
+
+	return email, nil
+}
+
+func (repository RepositoryImpl) countRecoveryCodes(userId int) (total int, err error) {
+	err = repository.Get(&total, "SELECT COUNT(*) FROM recovery_code WHERE user_id = ? AND used_at IS NULL", userId)
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (repository RepositoryImpl) enable(userId int, step int64, codeHashes []string) error {
+	tx, err := repository.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func(tx *sqlx.Tx) {
+		err := tx.Rollback()
+		if err != nil {
+			return
+		}
+	}(tx)
+
+	_, err = tx.NamedExec("UPDATE totp SET enabled_at = NOW(), last_used_step = :step WHERE user_id = :userId AND enabled_at IS NULL", map[string]any{
+		"step":   step,
+		"userId": userId,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = saveRecoveryCodes(tx, userId, codeHashes)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// useStep only affects newer steps so the same code cannot be used twice
+func (repository RepositoryImpl) useStep(userId int, step int64) (affectedRows int64, err error) {
+	result, err := repository.NamedExec("UPDATE totp SET last_used_step = :step WHERE user_id = :userId AND enabled_at IS NOT NULL AND last_used_step < :step", map[string]any{
+		"step":   step,
+		"userId": userId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
+// useRecoveryCode only affects unused code so two concurrent logins cannot both use it
+func (repository RepositoryImpl) useRecoveryCode(userId int, codeHash string) (affectedRows int64, err error) {
+	result, err := repository.NamedExec("UPDATE recovery_code SET used_at = NOW() WHERE user_id = :userId AND code_hash = :codeHash AND used_at IS NULL", map[string]any{
+		"userId":   userId,
+		"codeHash": codeHash,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
+func (repository RepositoryImpl) replaceRecoveryCodes(userId int, codeHashes []string) error {
+	tx, err := repository.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func(tx *sqlx.Tx) {
+		err := tx.Rollback()
+		if err != nil {
+			return
+		}
+	}(tx)
+
+	_, err = tx.Exec("DELETE FROM recovery_code WHERE user_id = ?", userId)
+	if err != nil {
+		return err
+	}
+
+	err = saveRecoveryCodes(tx, userId, codeHashes)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// reserveAttempt counts the attempt as failed before the code is checked and returns the counted attempts
+// the row is locked so parallel attempts cannot all be checked before the first one fails
+// nothing is counted while the user is locked
+func (repository RepositoryImpl) reserveAttempt(userId int) (Totp, error) {
+	tx, err := repository.Beginx()
+	if err != nil {
+		return Totp{}, err
+	}
+	defer func(tx *sqlx.Tx) {
+		err := tx.Rollback()
+		if err != nil {
+			return
+		}
+	}(tx)
+
+	var totp Totp
+	err = tx.Get(&totp, "SELECT * FROM totp WHERE user_id = ? FOR UPDATE", userId)
+	if err != nil {
+		return Totp{}, err
+	}
+
+	if totp.IsLocked() {
+		return totp, nil
+	}
+
+	_, err = tx.Exec("UPDATE totp SET failed_attempts = failed_attempts + 1 WHERE user_id = ?", userId)
+	if err != nil {
+		return Totp{}, err
+	}
+	totp.FailedAttempts++
+
+	err = tx.Commit()
+	if err != nil {
+		return Totp{}, err
+	}
+
+	return totp, nil
+}
+
+func (repository RepositoryImpl) resetAttempts(userId int) error {
+	_, err := repository.Exec("UPDATE totp SET failed_attempts = 0 WHERE user_id = ?", userId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// lock also revokes every challenge issued until now
+func (repository RepositoryImpl) lock(userId int, until time.Time) error {
+	_, err := repository.NamedExec("UPDATE totp SET failed_attempts = 0, locked_until = :until, challenges_revoked_at = :now WHERE user_id = :userId", map[string]any{
+		"until":  until,
+		"now":    time.Now(),
+		"userId": userId,
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// useChallenge revokes the challenge and every challenge issued before it
+// it only affects challenges that are not revoked yet so two concurrent verifies cannot both use it
+func (repository RepositoryImpl) useChallenge(userId int, issuedAt time.Time) (affectedRows int64, err error) {
+	result, err := repository.NamedExec("UPDATE totp SET challenges_revoked_at = :issuedAt WHERE user_id = :userId AND (challenges_revoked_at IS NULL OR challenges_revoked_at < :issuedAt)", map[string]any{
+		"issuedAt": issuedAt,
+		"userId":   userId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
+func (repository RepositoryImpl) delete(userId int) (affectedRows int64, err error) {
+	tx, err := repository.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer func(tx *sqlx.Tx) {
+		err := tx.Rollback()
+		if err != nil {
+			return
+		}
+	}(tx)
+
+	_, err = tx.Exec("DELETE FROM recovery_code WHERE user_id = ?", userId)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec("DELETE FROM totp WHERE user_id = ?", userId)
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
+func saveRecoveryCodes(tx *sqlx.Tx, userId int, codeHashes []string) error {
+	for _, codeHash := range codeHashes {
+		_, err := tx.NamedExec("INSERT INTO recovery_code (code_hash, user_id) VALUES (:codeHash, :userId)", map[string]any{
+			"codeHash": codeHash,
+			"userId":   userId,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
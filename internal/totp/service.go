@@ -0,0 +1,383 @@
+package totp
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"log"
+	"os"
+	"social-media-application/internal/audit"
+	"social-media-application/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultIssuer       = "go-social-media"
+	recoveryCodeCount   = 10
+	recoveryCodeByteLen = 10
+
+	defaultMaxFailedAttempts = 5
+	defaultLockoutInMinute   = 15
+)
+
+var ErrTooManyAttempts = errors.New("too many invalid codes, try again later")
+
+type (
+	Service interface {
+		enroll(userId int) (secret, uri string, err error)
+		enable(userId int, code string) (recoveryCodes []string, err error)
+		disable(userId int, code string) (affectedRows int64, err error)
+
+		regenerateRecoveryCodes(userId int, code string) (recoveryCodes []string, err error)
+
+		getStatus(userId int) (Status, error)
+
+		verify(userId int, code string) error
+		verifyChallenge(userId int, issuedAt time.Time, code string) error
+		loginFailed(userId int, loginMethod, reason string, origin audit.Origin)
+
+		IsEnabled(userId int) (bool, error)
+	}
+
+	ServiceImpl struct {
+		repository   Repository
+		auditService audit.Service
+	}
+)
+
+func NewService(repository Repository, auditService audit.Service) Service {
+	return &ServiceImpl{
+		repository:   repository,
+		auditService: auditService,
+	}
+}
+
+func (s ServiceImpl) enroll(userId int) (secret, uri string, err error) {
+	if userId <= 0 {
+		return "", "", errors.New("user id is required")
+	}
+
+	isEnabled, err := s.IsEnabled(userId)
+	if err != nil {
+		return "", "", err
+	}
+
+	if isEnabled {
+		return "", "", errors.New("two factor authentication is already enabled")
+	}
+
+	email, err := s.repository.findEmail(userId)
+	if err != nil {
+		return "", "", err
+	}
+
+	secret, err = generateSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	_, err = s.repository.save(userId, secret)
+	if err != nil {
+		return "", "", err
+	}
+
+	return secret, keyUri(issuer(), email, secret), nil
+}
+
+// enable requires a valid code so users cannot lock themselves out with a secret they did not save
+func (s ServiceImpl) enable(userId int, code string) (recoveryCodes []string, err error) {
+	if userId <= 0 {
+		return nil, errors.New("user id is required")
+	}
+
+	totp, err := s.repository.findBy(userId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("two factor authentication is not enrolled")
+		}
+
+		return nil, err
+	}
+
+	if totp.IsEnabled() {
+		return nil, errors.New("two factor authentication is already enabled")
+	}
+
+	step, ok := validateCode(totp.Secret, code, time.Now())
+	if !ok {
+		return nil, errors.New("invalid code")
+	}
+
+	recoveryCodes, codeHashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repository.enable(userId, step, codeHashes)
+	if err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+func (s ServiceImpl) disable(userId int, code string) (affectedRows int64, err error) {
+	err = s.verify(userId, code)
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = s.repository.delete(userId)
+	if err != nil {
+		return 0, err
+	}
+
+	if affectedRows <= 0 {
+		return 0, errors.New("no affected rows")
+	}
+
+	return affectedRows, nil
+}
+
+func (s ServiceImpl) regenerateRecoveryCodes(userId int, code string) (recoveryCodes []string, err error) {
+	err = s.verify(userId, code)
+	if err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, codeHashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repository.replaceRecoveryCodes(userId, codeHashes)
+	if err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+func (s ServiceImpl) getStatus(userId int) (Status, error) {
+	if userId <= 0 {
+		return Status{}, errors.New("user id is required")
+	}
+
+	isEnabled, err := s.IsEnabled(userId)
+	if err != nil {
+		return Status{}, err
+	}
+
+	if !isEnabled {
+		return Status{}, nil
+	}
+
+	total, err := s.repository.countRecoveryCodes(userId)
+	if err != nil {
+		return Status{}, err
+	}
+
+	return Status{
+		IsEnabled:              true,
+		RemainingRecoveryCodes: total,
+	}, nil
+}
+
+// verify accepts either the current code of the authenticator app or an unused recovery code
+// after TOTP_MAX_FAILED_ATTEMPTS invalid codes the user is locked for TOTP_LOCKOUT_IN_MINUTE and every challenge is revoked
+func (s ServiceImpl) verify(userId int, code string) error {
+	if userId <= 0 {
+		return errors.New("user id is required")
+	}
+
+	if strings.TrimSpace(code) == "" {
+		return errors.New("code is required")
+	}
+
+	totp, err := s.repository.reserveAttempt(userId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("two factor authentication is not enabled")
+		}
+
+		return err
+	}
+
+	if !totp.IsEnabled() {
+		return errors.New("two factor authentication is not enabled")
+	}
+
+	if totp.IsLocked() {
+		return ErrTooManyAttempts
+	}
+
+	// Only reached by parallel attempts since the user is locked once it reaches the maximum
+	maxFailedAttempts := intFromEnv("TOTP_MAX_FAILED_ATTEMPTS", defaultMaxFailedAttempts)
+	if totp.FailedAttempts > maxFailedAttempts {
+		s.lock(userId)
+		return ErrTooManyAttempts
+	}
+
+	err = s.checkCode(totp, code)
+	if err != nil {
+		if totp.FailedAttempts >= maxFailedAttempts {
+			s.lock(userId)
+			return ErrTooManyAttempts
+		}
+
+		return err
+	}
+
+	err = s.repository.resetAttempts(userId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// verifyChallenge is the second step of the login, challenges issued before the last lock or the last verified challenge are rejected
+func (s ServiceImpl) verifyChallenge(userId int, issuedAt time.Time, code string) error {
+	if userId <= 0 {
+		return errors.New("user id is required")
+	}
+
+	totp, err := s.repository.findBy(userId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("two factor authentication is not enabled")
+		}
+
+		return err
+	}
+
+	if totp.IsChallengeRevoked(issuedAt) {
+		return errors.New("challenge was revoked, login again")
+	}
+
+	err = s.verify(userId, code)
+	if err != nil {
+		return err
+	}
+
+	// Used up only after a valid code so a typo does not send the user back to the first factor
+	affectedRows, err := s.repository.useChallenge(userId, issuedAt)
+	if err != nil {
+		return err
+	}
+
+	if affectedRows <= 0 {
+		return errors.New("challenge was already used, login again")
+	}
+
+	return nil
+}
+
+// loginFailed is recorded like a wrong password so guessing the code shows up in the audit log
+func (s ServiceImpl) loginFailed(userId int, loginMethod, reason string, origin audit.Origin) {
+	s.auditService.Record(audit.LOGIN_FAILED, userId, origin, audit.Metadata{
+		"login_method": loginMethod,
+		"reason":       reason,
+	})
+}
+
+func (s ServiceImpl) lock(userId int) {
+	duration := time.Duration(intFromEnv("TOTP_LOCKOUT_IN_MINUTE", defaultLockoutInMinute)) * time.Minute
+	err := s.repository.lock(userId, time.Now().Add(duration))
+	if err != nil {
+		log.Println("WARNING: failed to lock two factor authentication of user", userId, err)
+		return
+	}
+
+	log.Printf("security event: two factor authentication of user %d locked for %s after too many invalid codes", userId, duration)
+}
+
+func (s ServiceImpl) checkCode(totp Totp, code string) error {
+	if step, ok := validateCode(totp.Secret, code, time.Now()); ok {
+		affectedRows, err := s.repository.useStep(totp.UserId, step)
+		if err != nil {
+			return err
+		}
+
+		if affectedRows <= 0 {
+			return errors.New("code was already used")
+		}
+
+		return nil
+	}
+
+	affectedRows, err := s.repository.useRecoveryCode(totp.UserId, utils.HashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+
+	if affectedRows <= 0 {
+		return errors.New("invalid code")
+	}
+
+	return nil
+}
+
+func (s ServiceImpl) IsEnabled(userId int) (bool, error) {
+	if userId <= 0 {
+		return false, errors.New("user id is required")
+	}
+
+	totp, err := s.repository.findBy(userId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return totp.IsEnabled(), nil
+}
+
+// generateRecoveryCodes returns the plain codes shown once to the user and the hashes to be stored
+func generateRecoveryCodes() (recoveryCodes, codeHashes []string, err error) {
+	recoveryCodes = make([]string, 0, recoveryCodeCount)
+	codeHashes = make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		bytes := make([]byte, recoveryCodeByteLen)
+		_, err := rand.Read(bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(encoding.EncodeToString(bytes))
+		code = code[:5] + "-" + code[5:10]
+
+		recoveryCodes = append(recoveryCodes, code)
+		codeHashes = append(codeHashes, utils.HashToken(normalizeRecoveryCode(code)))
+	}
+
+	return recoveryCodes, codeHashes, nil
+}
+
+// normalizeRecoveryCode so codes typed in upper case or without the dash still match
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
+
+func intFromEnv(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+
+	return value
+}
+
+func issuer() string {
+	issuer := os.Getenv("TOTP_ISSUER")
+	if strings.TrimSpace(issuer) == "" {
+		return defaultIssuer
+	}
+
+	return issuer
+}
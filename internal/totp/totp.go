@@ -0,0 +1,39 @@
+package totp
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Totp secret is only returned once during enrollment
+type Totp struct {
+	Id           int          `json:"id" db:"id"`
+	CreatedAt    time.Time    `json:"created_at" db:"created_at"`
+	Secret       string       `json:"-" db:"secret"`
+	EnabledAt    sql.NullTime `json:"enabled_at" db:"enabled_at"`
+	LastUsedStep int64        `json:"-" db:"last_used_step"`
+	UserId       int          `json:"user_id" db:"user_id"`
+
+	// Invalid codes are counted so a code cannot be guessed by logging in again and again
+	FailedAttempts      int          `json:"-" db:"failed_attempts"`
+	LockedUntil         sql.NullTime `json:"-" db:"locked_until"`
+	ChallengesRevokedAt sql.NullTime `json:"-" db:"challenges_revoked_at"`
+}
+
+type Status struct {
+	IsEnabled              bool `json:"is_enabled"`
+	RemainingRecoveryCodes int  `json:"remaining_recovery_codes"`
+}
+
+func (t Totp) IsEnabled() bool {
+	return t.EnabledAt.Valid // If theres a value it is enabled
+}
+
+func (t Totp) IsLocked() bool {
+	return t.LockedUntil.Valid && time.Now().Before(t.LockedUntil.Time)
+}
+
+// IsChallengeRevoked challenges issued before the lock or the last verified challenge cannot be used anymore so the password has to be entered again
+func (t Totp) IsChallengeRevoked(issuedAt time.Time) bool {
+	return t.ChallengesRevokedAt.Valid && !issuedAt.After(t.ChallengesRevokedAt.Time)
+}
@@ -1,11 +1,19 @@
 package user
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
+	"log"
+	"math"
 	"net/http"
+	"slices"
+	"social-media-application/internal/audit"
+	"social-media-application/internal/lockout"
 	"social-media-application/internal/paging"
+	pat "social-media-application/internal/personal_access_token"
 	"social-media-application/internal/refresh"
-	pd "social-media-application/internal/user/password"
+	"social-media-application/internal/role"
+	"social-media-application/internal/totp"
 	"social-media-application/middlewares"
 	"social-media-application/utils"
 	"strconv"
@@ -27,23 +35,35 @@ type (
 		changeAttachment(ctx *gin.Context)
 		changeStatus(ctx *gin.Context)
 		changePassword(ctx *gin.Context)
+		setPassword(ctx *gin.Context)
 
 		login(ctx *gin.Context)
 		logout(ctx *gin.Context)
 
+		verifyEmail(ctx *gin.Context)
+		resendVerification(ctx *gin.Context)
+
 		RegisterRoutes(c *gin.Engine)
 	}
 
 	ControllerImpl struct {
 		service        Service
 		refreshService refresh.Service
+		patService     pat.Service
+		roleService    role.Service
+		totpService    totp.Service
+		loginGuard     *lockout.Guard
 	}
 )
 
-func NewController(service Service, refreshService refresh.Service) Controller {
+func NewController(service Service, refreshService refresh.Service, patService pat.Service, roleService role.Service, totpService totp.Service, loginGuard *lockout.Guard) Controller {
 	return &ControllerImpl{
 		service:        service,
 		refreshService: refreshService,
+		patService:     patService,
+		roleService:    roleService,
+		totpService:    totpService,
+		loginGuard:     loginGuard,
 	}
 }
 
@@ -54,19 +74,23 @@ func (c *ControllerImpl) RegisterRoutes(e *gin.Engine) {
 		r.POST("/login", c.login)
 		r.POST("/logout", c.logout)
 		r.POST("", c.save)
+		r.POST("/verification", c.verifyEmail)
+		r.POST("/verification/resend", c.resendVerification)
 
 		r.GET("/id/:id", c.getById)
 		r.GET("/email/:email", c.getByEmail)
 		r.GET("", c.getAll)
 
-		r.DELETE("/:id", c.deleteById)
-
-		r.PATCH("/:id/attachment", c.changeAttachment)
-		r.PATCH("/:id/status", c.changeStatus)
-		r.PATCH("/:id/password", c.changePassword)
-
 		// Protected
 		r.GET("/jwt", middleware.JWT, c.getByJWT)
+		r.POST("/password", middleware.JWT, c.setPassword)
+
+		// Owner or users:manage
+		r.DELETE("/:id", middleware.JWT, middleware.OwnerOrPermission("id", role.USERS_MANAGE), c.deleteById)
+
+		r.PATCH("/:id/attachment", middleware.JWT, middleware.OwnerOrPermission("id", role.USERS_MANAGE), c.changeAttachment)
+		r.PATCH("/:id/status", middleware.JWT, middleware.OwnerOrPermission("id", role.USERS_MANAGE), c.changeStatus)
+		r.PATCH("/:id/password", middleware.JWT, middleware.OwnerOrPermission("id", role.USERS_MANAGE), c.changePassword)
 	}
 }
 
@@ -106,7 +130,7 @@ func (c *ControllerImpl) getByJWT(ctx *gin.Context) {
 		return
 	}
 
-	user, err := c.service.getById(sub)
+	user, err := c.service.GetById(sub)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "get by jwt failed " + err.Error(),
@@ -126,7 +150,7 @@ func (c *ControllerImpl) getById(ctx *gin.Context) {
 		return
 	}
 
-	user, err := c.service.getById(id)
+	user, err := c.service.GetById(id)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "get by id failed " + err.Error(),
@@ -242,7 +266,18 @@ func (c *ControllerImpl) changeStatus(ctx *gin.Context) {
 		return
 	}
 
-	_, err = c.service.changeStatus(id, status)
+	// Owners can only deactivate, otherwise a deactivated user could reactivate themselves with a token issued before
+	if status {
+		permissions, err := middleware.GetPermissions(ctx)
+		if err != nil || !slices.Contains(permissions, role.USERS_MANAGE) {
+			ctx.JSON(http.StatusForbidden, gin.H{
+				"message": "change status failed activating a user requires permission " + role.USERS_MANAGE,
+			})
+			return
+		}
+	}
+
+	_, err = c.service.changeStatus(id, status, audit.NewOrigin(ctx))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "change status failed " + err.Error(),
@@ -273,7 +308,16 @@ func (c *ControllerImpl) changePassword(ctx *gin.Context) {
 		return
 	}
 
-	_, err = c.service.changePassword(id, passwordRequest.Password)
+	_, err = c.service.ChangePassword(id, passwordRequest.Password, audit.NewOrigin(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "change password failed " + err.Error(),
+		})
+		return
+	}
+
+	// Personal access tokens outlive the login, so the ones made with the old password are revoked
+	_, err = c.patService.RevokeAllBy(id)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "change password failed " + err.Error(),
@@ -284,6 +328,37 @@ func (c *ControllerImpl) changePassword(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, id)
 }
 
+func (c *ControllerImpl) setPassword(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "set password failed " + err.Error(),
+		})
+		return
+	}
+
+	passwordRequest := struct {
+		Password string `json:"password" binding:"required"`
+	}{}
+
+	if err := ctx.ShouldBind(&passwordRequest); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "set password failed " + err.Error(),
+		})
+		return
+	}
+
+	_, err = c.service.setPassword(sub, passwordRequest.Password, audit.NewOrigin(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "set password failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, sub)
+}
+
 func (c *ControllerImpl) login(ctx *gin.Context) {
 	request := struct {
 		Username string `json:"username" binding:"required"`
@@ -297,8 +372,33 @@ func (c *ControllerImpl) login(ctx *gin.Context) {
 		return
 	}
 
+	// Checked before the password so brute forcing does not cost a bcrypt comparison
+	// it also reserves the attempt so parallel requests cannot all be compared before the first one fails
+	retryAfter, err := c.loginGuard.Check(request.Username, ctx.ClientIP())
+	if errors.Is(err, lockout.ErrTooManyAttempts) {
+		// Recorded against the account when it exists so the locked user can see it in their audit log
+		userId := 0
+		if u, err := c.service.GetByEmail(request.Username); err == nil {
+			userId = u.Id
+		}
+
+		c.service.loginFailed(userId, request.Username, "too_many_attempts", audit.NewOrigin(ctx))
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		ctx.JSON(http.StatusTooManyRequests, gin.H{
+			"message": "login failed! " + err.Error(),
+		})
+		return
+	}
+
+	// An unreachable store lets the login through like the rate limiter does
+	if err != nil {
+		log.Println("WARNING: login lockout check failed", err)
+	}
+
 	user, err := c.service.GetByEmail(request.Username)
 	if err != nil {
+		c.loginGuard.Fail(request.Username, ctx.ClientIP())
+		c.service.loginFailed(0, request.Username, "unknown_email", audit.NewOrigin(ctx))
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"message": "login failed! invalid credentials",
 		})
@@ -307,20 +407,63 @@ func (c *ControllerImpl) login(ctx *gin.Context) {
 
 	// Meaning it was social login
 	if strings.TrimSpace(user.Password) == "" {
+		c.loginGuard.Fail(request.Username, ctx.ClientIP())
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"message": "login failed! Please login via social account",
 		})
 		return
 	}
 
-	if !pd.IsPasswordMatch(request.Password, user.Password) {
+	if !c.service.isPasswordMatch(request.Password, user.Password) {
+		c.loginGuard.Fail(request.Username, ctx.ClientIP())
+		c.service.loginFailed(user.Id, request.Username, "invalid_password", audit.NewOrigin(ctx))
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"message": "login failed! invalid credentials",
 		})
 		return
 	}
+	c.loginGuard.Succeed(request.Username, ctx.ClientIP())
+
+	// Hashes made with an older algorithm or cost are upgraded while the password is known
+	// a failed upgrade is retried on the next login so it does not fail this one
+	err = c.service.rehashPassword(user, request.Password)
+	if err != nil {
+		log.Println("WARNING: rehashing password failed for user", user.Id, err)
+	}
+
+	if isEmailVerificationRequired() && !user.IsEmailVerified() {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"message": "login failed! email is not verified",
+		})
+		return
+	}
+
+	isTotpEnabled, err := c.totpService.IsEnabled(user.Id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "login failed! " + err.Error(),
+		})
+		return
+	}
+
+	// Tokens are only issued after the code is verified in /users/totp/verify
+	if isTotpEnabled {
+		challenge, err := middleware.GenerateChallenge(user.Id, refresh.PASSWORD)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"message": "login failed! " + err.Error(),
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":   "two factor authentication required",
+			"challenge": challenge,
+		})
+		return
+	}
 
-	accessToken, err := middleware.GenerateJWT(user.Id)
+	roles, permissions, err := c.roleService.GetClaims(user.Id)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "login failed! " + err.Error(),
@@ -328,7 +471,7 @@ func (c *ControllerImpl) login(ctx *gin.Context) {
 		return
 	}
 
-	refreshToken, err := c.refreshService.Save(user.Id)
+	accessToken, err := middleware.GenerateJWT(user.Id, roles, permissions)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "login failed! " + err.Error(),
@@ -336,7 +479,7 @@ func (c *ControllerImpl) login(ctx *gin.Context) {
 		return
 	}
 
-	err = utils.SetTokens(ctx, accessToken, refreshToken)
+	refreshToken, err := c.refreshService.Save(user.Id, refresh.NewMetadata(ctx, refresh.PASSWORD))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "login failed! " + err.Error(),
@@ -344,14 +487,18 @@ func (c *ControllerImpl) login(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"message": "authentication successful",
-	})
+	err = utils.SendTokens(ctx, accessToken, refreshToken, "authentication successful")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "login failed! " + err.Error(),
+		})
+		return
+	}
 }
 
 func (c *ControllerImpl) logout(ctx *gin.Context) {
 	// Invalidate refresh token in database
-	refreshToken, err := ctx.Cookie("refreshToken")
+	refreshToken, err := utils.GetRefreshToken(ctx)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"message": "logout failed no logged in user",
@@ -359,7 +506,7 @@ func (c *ControllerImpl) logout(ctx *gin.Context) {
 		return
 	}
 
-	_, err = c.refreshService.RevokeByToken(refreshToken)
+	_, err = c.refreshService.RevokeByToken(refreshToken, audit.NewOrigin(ctx))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "logout failed! " + err.Error(),
@@ -369,3 +516,53 @@ func (c *ControllerImpl) logout(ctx *gin.Context) {
 
 	utils.ClearTokens(ctx)
 }
+
+func (c *ControllerImpl) verifyEmail(ctx *gin.Context) {
+	request := struct {
+		Token string `json:"token" binding:"required"`
+	}{}
+
+	if err := ctx.ShouldBind(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "verify email failed " + err.Error(),
+		})
+		return
+	}
+
+	err := c.service.verifyEmail(request.Token)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "verify email failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "email verified",
+	})
+}
+
+func (c *ControllerImpl) resendVerification(ctx *gin.Context) {
+	request := struct {
+		Email string `json:"email" binding:"required"`
+	}{}
+
+	if err := ctx.ShouldBind(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "resend verification failed " + err.Error(),
+		})
+		return
+	}
+
+	err := c.service.resendVerification(request.Email)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "resend verification failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message": "if the email is registered and not yet verified a verification link was sent",
+	})
+}
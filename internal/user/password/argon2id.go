@@ -0,0 +1,146 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"strings"
+)
+
+// Defaults are the OWASP recommendation of 64 MiB, 3 iterations, and 2 threads
+const (
+	defaultArgon2idMemory      = 64 * 1024
+	defaultArgon2idIterations  = 3
+	defaultArgon2idParallelism = 2
+
+	argon2idSaltLength = 16
+	argon2idKeyLength  = 32
+)
+
+type (
+	// Argon2idParams memory is in KiB
+	Argon2idParams struct {
+		Memory      uint32
+		Iterations  uint32
+		Parallelism uint8
+	}
+
+	argon2idHasher struct {
+		params Argon2idParams
+	}
+)
+
+func NewArgon2idHasher(params Argon2idParams) (Hasher, error) {
+	if params.Memory < 8*uint32(params.Parallelism) {
+		return nil, errors.New("argon2id memory should be at least 8 KiB per thread")
+	}
+
+	if params.Iterations == 0 {
+		return nil, errors.New("argon2id iterations is required")
+	}
+
+	if params.Parallelism == 0 {
+		return nil, errors.New("argon2id parallelism is required")
+	}
+
+	return &argon2idHasher{
+		params: params,
+	}, nil
+}
+
+// Hash uses the PHC string format $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+func (h argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2idSaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, argon2idKeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		h.params.Memory,
+		h.params.Iterations,
+		h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h argon2idHasher) Verify(password, hash string) bool {
+	return verify(password, hash)
+}
+
+func (h argon2idHasher) NeedsRehash(hash string) bool {
+	if !isArgon2id(hash) {
+		return true
+	}
+
+	params, _, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+
+	return params != h.params || len(key) != argon2idKeyLength
+}
+
+func (h argon2idHasher) MaxBytes() int {
+	return 0
+}
+
+func isArgon2id(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+func verifyArgon2id(password, hash string) bool {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false
+	}
+
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1
+}
+
+func decodeArgon2id(hash string) (params Argon2idParams, salt, key []byte, err error) {
+	// "", "argon2id", "v=19", "m=65536,t=3,p=2", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return Argon2idParams{}, nil, nil, errors.New("argon2id hash is malformed")
+	}
+
+	var version int
+	_, err = fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil {
+		return Argon2idParams{}, nil, nil, err
+	}
+
+	if version != argon2.Version {
+		return Argon2idParams{}, nil, nil, errors.New("argon2id version is not supported")
+	}
+
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil {
+		return Argon2idParams{}, nil, nil, err
+	}
+
+	salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2idParams{}, nil, nil, err
+	}
+
+	key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return Argon2idParams{}, nil, nil, err
+	}
+
+	if len(key) == 0 {
+		return Argon2idParams{}, nil, nil, errors.New("argon2id key is empty")
+	}
+
+	return params, salt, key, nil
+}
@@ -1,37 +1,68 @@
 package password
 
 import (
-	"errors"
 	"fmt"
 	"golang.org/x/crypto/bcrypt"
+	"strings"
 )
 
-func Encrypt(password string) (string, error) {
-	const LEN = 8
-	if len(password) < LEN {
-		return "", errors.New(fmt.Sprintf("should be %d letters long", LEN))
-	}
+const (
+	// defaultBcryptCost was the hardcoded cost before it was configurable so existing hashes are not rehashed
+	defaultBcryptCost = 14
+
+	// bcryptMaxBytes longer passwords are rejected by bcrypt
+	bcryptMaxBytes = 72
+)
+
+type bcryptHasher struct {
+	cost int
+}
 
-	if !hasLowerCase(password) {
-		return "", errors.New("should contain lowercase letters")
+func NewBcryptHasher(cost int) (Hasher, error) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost should be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
 	}
 
-	if !hasUpperCase(password) {
-		return "", errors.New("should contain uppercase letters")
+	return &bcryptHasher{
+		cost: cost,
+	}, nil
+}
+
+func (h bcryptHasher) Hash(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
 	}
 
-	if !hasSpecialChar(password) {
-		return "", errors.New("should contain special characters")
+	return string(bytes), nil
+}
+
+func (h bcryptHasher) Verify(password, hash string) bool {
+	return verify(password, hash)
+}
+
+func (h bcryptHasher) NeedsRehash(hash string) bool {
+	if !isBcrypt(hash) {
+		return true
 	}
 
-	if !hasDigit(password) {
-		return "", errors.New("should contain digits")
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return true
 	}
 
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
-	return string(bytes), err
+	return cost != h.cost
+}
+
+func (h bcryptHasher) MaxBytes() int {
+	return bcryptMaxBytes
+}
+
+// isBcrypt bcrypt hashes look like $2a$14$<salt and hash>
+func isBcrypt(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
 }
 
-func IsPasswordMatch(password, hash string) bool {
+func verifyBcrypt(password, hash string) bool {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
 }
@@ -0,0 +1,66 @@
+package password
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Algorithms
+const (
+	BCRYPT   = "bcrypt"
+	ARGON2ID = "argon2id"
+)
+
+// Hasher hashes new passwords with the configured algorithm but verifies every supported format
+// since the hashes describe their own algorithm and parameters, so switching algorithms does not lock anyone out
+type Hasher interface {
+	Hash(password string) (string, error)
+	Verify(password, hash string) bool
+
+	// NeedsRehash is true when the hash was made with another algorithm or parameters
+	NeedsRehash(hash string) bool
+
+	// MaxBytes is the longest password in bytes the algorithm accepts, 0 when there's no limit
+	MaxBytes() int
+}
+
+// HasherFromEnv reads PASSWORD_HASH_ALGORITHM (bcrypt or argon2id), PASSWORD_BCRYPT_COST,
+// PASSWORD_ARGON2ID_MEMORY_IN_KB, PASSWORD_ARGON2ID_ITERATIONS, and PASSWORD_ARGON2ID_PARALLELISM
+func HasherFromEnv() (Hasher, error) {
+	algorithm := strings.ToLower(strings.TrimSpace(os.Getenv("PASSWORD_HASH_ALGORITHM")))
+	switch algorithm {
+	case "", BCRYPT:
+		return NewBcryptHasher(intFromEnv("PASSWORD_BCRYPT_COST", defaultBcryptCost))
+	case ARGON2ID:
+		return NewArgon2idHasher(Argon2idParams{
+			Memory:      uint32(intFromEnv("PASSWORD_ARGON2ID_MEMORY_IN_KB", defaultArgon2idMemory)),
+			Iterations:  uint32(intFromEnv("PASSWORD_ARGON2ID_ITERATIONS", defaultArgon2idIterations)),
+			Parallelism: uint8(intFromEnv("PASSWORD_ARGON2ID_PARALLELISM", defaultArgon2idParallelism)),
+		})
+	default:
+		return nil, fmt.Errorf("unsupported password hash algorithm %s", algorithm)
+	}
+}
+
+// verify picks the algorithm from the hash prefix
+func verify(password, hash string) bool {
+	switch {
+	case isArgon2id(hash):
+		return verifyArgon2id(password, hash)
+	case isBcrypt(hash):
+		return verifyBcrypt(password, hash)
+	default:
+		return false
+	}
+}
+
+func intFromEnv(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+
+	return value
+}
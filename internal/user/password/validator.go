@@ -1,6 +1,140 @@
 package password
 
-import "unicode"
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Character classes
+const (
+	LOWERCASE = "lowercase"
+	UPPERCASE = "uppercase"
+	DIGIT     = "digit"
+	SPECIAL   = "special"
+)
+
+const (
+	defaultMinLength = 8
+	defaultMaxLength = 64
+)
+
+var AllCharacterClasses = []string{LOWERCASE, UPPERCASE, DIGIT, SPECIAL}
+
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+// commonPasswords are compared in lower case so a capitalized common password is still denied
+var commonPasswords = parseCommonPasswords(commonPasswordsFile)
+
+// Policy checks the password strength, it's separate from the hasher so a weak password is rejected before it is hashed
+type Policy struct {
+	MinLength        int
+	MaxLength        int
+	CharacterClasses []string
+	DenyCommon       bool
+
+	// MaxBytes is the limit of the hasher, lengths are counted in characters but bcrypt counts bytes
+	MaxBytes int
+}
+
+// PolicyFromEnv reads PASSWORD_MIN_LENGTH, PASSWORD_MAX_LENGTH, PASSWORD_CHARACTER_CLASSES, and PASSWORD_DENY_COMMON
+// the hasher is needed so a password it cannot hash is rejected by the policy instead of failing to hash
+func PolicyFromEnv(hasher Hasher) Policy {
+	characterClasses := AllCharacterClasses
+	if value, ok := os.LookupEnv("PASSWORD_CHARACTER_CLASSES"); ok {
+		characterClasses = make([]string, 0)
+		for _, class := range strings.Split(value, ",") {
+			class = strings.ToLower(strings.TrimSpace(class))
+			if slices.Contains(AllCharacterClasses, class) {
+				characterClasses = append(characterClasses, class)
+			}
+		}
+	}
+
+	denyCommon, err := strconv.ParseBool(os.Getenv("PASSWORD_DENY_COMMON"))
+	if err != nil {
+		denyCommon = true
+	}
+
+	policy := Policy{
+		MinLength:        intFromEnv("PASSWORD_MIN_LENGTH", defaultMinLength),
+		MaxLength:        intFromEnv("PASSWORD_MAX_LENGTH", defaultMaxLength),
+		CharacterClasses: characterClasses,
+		DenyCommon:       denyCommon,
+		MaxBytes:         hasher.MaxBytes(),
+	}
+
+	if policy.MaxBytes > 0 && policy.MaxLength > policy.MaxBytes {
+		log.Printf("WARNING: PASSWORD_MAX_LENGTH is more than the %d bytes the hasher accepts! defaulted to %d", policy.MaxBytes, policy.MaxBytes)
+		policy.MaxLength = policy.MaxBytes
+	}
+
+	return policy
+}
+
+// Validate checks the password strength without hashing it
+func (p Policy) Validate(password string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		return fmt.Errorf("should be at least %d characters long", p.MinLength)
+	}
+
+	if p.MaxLength > 0 && length > p.MaxLength {
+		return fmt.Errorf("should be at most %d characters long", p.MaxLength)
+	}
+
+	// Characters like emojis or accented letters are more than one byte so this is checked even when MaxLength is lower
+	if p.MaxBytes > 0 && len(password) > p.MaxBytes {
+		return fmt.Errorf("should be at most %d bytes long, characters like emojis or accented letters count as more than one", p.MaxBytes)
+	}
+
+	if slices.Contains(p.CharacterClasses, LOWERCASE) && !hasLowerCase(password) {
+		return errors.New("should contain lowercase letters")
+	}
+
+	if slices.Contains(p.CharacterClasses, UPPERCASE) && !hasUpperCase(password) {
+		return errors.New("should contain uppercase letters")
+	}
+
+	if slices.Contains(p.CharacterClasses, SPECIAL) && !hasSpecialChar(password) {
+		return errors.New("should contain special characters")
+	}
+
+	if slices.Contains(p.CharacterClasses, DIGIT) && !hasDigit(password) {
+		return errors.New("should contain digits")
+	}
+
+	if p.DenyCommon && isCommon(password) {
+		return errors.New("is too common")
+	}
+
+	return nil
+}
+
+func isCommon(password string) bool {
+	_, ok := commonPasswords[strings.ToLower(password)]
+	return ok
+}
+
+func parseCommonPasswords(file string) map[string]struct{} {
+	passwords := make(map[string]struct{})
+	for _, line := range strings.Split(file, "\n") {
+		line = strings.ToLower(strings.TrimSpace(line))
+		if line != "" {
+			passwords[line] = struct{}{}
+		}
+	}
+
+	return passwords
+}
 
 func hasUpperCase(password string) bool {
 	for _, char := range password {
@@ -23,6 +23,8 @@ type (
 		changeAttachment(userId int, attachment string) (affectedRows int64, err error)
 		changeStatus(userId int, isActive bool) (affectedRows int64, err error)
 		changePassword(userId int, newPassword string) (affectedRows int64, err error)
+		setPassword(userId int, password string) (affectedRows int64, err error)
+		rehashPassword(userId int, oldPassword, newPassword string) (affectedRows int64, err error)
 
 		isEmailExists(email string) (bool, error)
 	}
@@ -59,7 +61,8 @@ func (repository *RepositoryImpl) saveLocal(firstName, lastName, email, password
 }
 
 func (repository *RepositoryImpl) saveSocial(firstName, lastName, email string) (id int64, err error) {
-	result, err := repository.NamedExec(`INSERT INTO user (first_name, last_name, email) VALUES (:firstName, :lastName, :email)`, map[string]any{
+	// Social providers already verified the email
+	result, err := repository.NamedExec(`INSERT INTO user (first_name, last_name, email, email_verified_at) VALUES (:firstName, :lastName, :email, NOW())`, map[string]any{
 		"firstName": firstName,
 		"lastName":  lastName,
 		"email":     email,
@@ -192,6 +195,42 @@ func (repository *RepositoryImpl) changePassword(userId int, newPassword string)
 	return affectedRows, nil
 }
 
+func (repository *RepositoryImpl) rehashPassword(userId int, oldPassword, newPassword string) (affectedRows int64, err error) {
+	result, err := repository.NamedExec("UPDATE user SET password = :newPassword WHERE id = :userId AND password = :oldPassword", map[string]any{
+		"newPassword": newPassword,
+		"oldPassword": oldPassword,
+		"userId":      userId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
+// setPassword only affects users without a password so it cannot be used to change an existing one
+func (repository *RepositoryImpl) setPassword(userId int, password string) (affectedRows int64, err error) {
+	result, err := repository.NamedExec("UPDATE user SET password = :password WHERE id = :userId AND (password IS NULL OR password = '')", map[string]any{
+		"password": password,
+		"userId":   userId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
 func (repository *RepositoryImpl) isEmailExists(email string) (bool, error) {
 	var exists bool
 	err := repository.Get(&exists, "SELECT EXISTS(SELECT 1 FROM user WHERE email = ?)", email)
@@ -201,3 +240,40 @@ func (repository *RepositoryImpl) isEmailExists(email string) (bool, error) {
 
 	return exists, nil
 }
+
+// resetPassword runs in the transaction that uses the password reset token
+func resetPassword(tx *sqlx.Tx, userId int, newPassword string) (affectedRows int64, err error) {
+	result, err := tx.Exec("UPDATE user SET password = ? WHERE id = ?", newPassword, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
+// LockAndCountLoginMethods locks the user row and counts the password, linked social accounts, and passkeys
+// so two removals at the same time cannot remove every login method. Used by unlinking social accounts and deleting passkeys
+func LockAndCountLoginMethods(tx *sqlx.Tx, userId int) (int, error) {
+	var locked int
+	err := tx.Get(&locked, "SELECT id FROM user WHERE id = ? FOR UPDATE", userId)
+	if err != nil {
+		return 0, err
+	}
+
+	var loginMethods int
+	err = tx.Get(&loginMethods, `
+		SELECT
+			(SELECT COUNT(*) FROM user WHERE id = ? AND password IS NOT NULL AND password != '') +
+			(SELECT COUNT(*) FROM user_social WHERE user_id = ?) +
+			(SELECT COUNT(*) FROM webauthn_credential WHERE user_id = ?)`, userId, userId, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	return loginMethods, nil
+}
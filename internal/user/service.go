@@ -1,9 +1,16 @@
 package user
 
 import (
+	"database/sql"
 	"errors"
+	"github.com/jmoiron/sqlx"
+	"log"
+	"os"
+	"social-media-application/internal/audit"
 	"social-media-application/internal/paging"
 	pd "social-media-application/internal/user/password"
+	"social-media-application/internal/user/verification"
+	"strconv"
 	"strings"
 )
 
@@ -12,7 +19,7 @@ type (
 		saveLocal(firstName, lastName, email, password, attachment string) (id int64, err error)
 		SaveSocial(firstName, lastName, email string) (id int64, err error) // for social register
 
-		getById(id int) (User, error)
+		GetById(id int) (User, error)
 		GetByEmail(email string) (User, error)
 
 		getAll(isActive bool, request *paging.PageRequest) (*paging.Page[User], error)
@@ -20,18 +27,35 @@ type (
 		deleteById(id int) (affectedRows int64, err error)
 
 		changeAttachment(userId int, attachment string) (affectedRows int64, err error)
-		changeStatus(userId int, isActive bool) (affectedRows int64, err error)
-		changePassword(userId int, newPassword string) (affectedRows int64, err error)
+		changeStatus(userId int, isActive bool, origin audit.Origin) (affectedRows int64, err error)
+		ChangePassword(userId int, newPassword string, origin audit.Origin) (affectedRows int64, err error)
+		ResetPassword(tx *sqlx.Tx, userId int, newPassword string, origin audit.Origin) error // used by password reset in the transaction that uses the token
+		setPassword(userId int, password string, origin audit.Origin) (affectedRows int64, err error)
+
+		loginFailed(userId int, email, reason string, origin audit.Origin)
+		isPasswordMatch(password, hash string) bool
+		rehashPassword(user User, password string) error
+
+		verifyEmail(token string) error
+		resendVerification(email string) error
 	}
 
 	ServiceImpl struct {
-		repository Repository
+		repository          Repository
+		verificationService verification.Service
+		auditService        audit.Service
+		hasher              pd.Hasher
+		passwordPolicy      pd.Policy
 	}
 )
 
-func NewService(repository Repository) Service {
+func NewService(repository Repository, verificationService verification.Service, auditService audit.Service, hasher pd.Hasher, passwordPolicy pd.Policy) Service {
 	return &ServiceImpl{
-		repository: repository,
+		repository:          repository,
+		verificationService: verificationService,
+		auditService:        auditService,
+		hasher:              hasher,
+		passwordPolicy:      passwordPolicy,
 	}
 }
 
@@ -61,7 +85,12 @@ func (s ServiceImpl) saveLocal(firstName, lastName, email, password, attachment
 		return 0, errors.New("email already exists")
 	}
 
-	hashedPassword, err := pd.Encrypt(password)
+	err = s.passwordPolicy.Validate(password)
+	if err != nil {
+		return 0, err
+	}
+
+	hashedPassword, err := s.hasher.Hash(password)
 	if err != nil {
 		return 0, err
 	}
@@ -71,6 +100,13 @@ func (s ServiceImpl) saveLocal(firstName, lastName, email, password, attachment
 		return 0, err
 	}
 
+	// The user is already saved and can ask for another verification email
+	// So a failed send should not fail the request
+	err = s.verificationService.Send(int(id), email)
+	if err != nil {
+		log.Println("WARNING: sending verification failed for user", id, err)
+	}
+
 	return id, nil
 }
 
@@ -95,7 +131,7 @@ func (s ServiceImpl) SaveSocial(firstName, lastName, email string) (id int64, er
 	return id, nil
 }
 
-func (s ServiceImpl) getById(id int) (User, error) {
+func (s ServiceImpl) GetById(id int) (User, error) {
 	if id <= 0 {
 		return User{}, errors.New("user id is required")
 	}
@@ -168,7 +204,7 @@ func (s ServiceImpl) changeAttachment(userId int, attachment string) (affectedRo
 	return affectedRows, nil
 }
 
-func (s ServiceImpl) changeStatus(userId int, isActive bool) (affectedRows int64, err error) {
+func (s ServiceImpl) changeStatus(userId int, isActive bool, origin audit.Origin) (affectedRows int64, err error) {
 	if userId <= 0 {
 		return 0, errors.New("user id is required")
 	}
@@ -182,10 +218,14 @@ func (s ServiceImpl) changeStatus(userId int, isActive bool) (affectedRows int64
 		return 0, errors.New("no rows affected")
 	}
 
+	s.auditService.Record(audit.STATUS_CHANGED, userId, origin, audit.Metadata{
+		"is_active": isActive,
+	})
+
 	return affectedRows, nil
 }
 
-func (s ServiceImpl) changePassword(userId int, newPassword string) (affectedRows int64, err error) {
+func (s ServiceImpl) ChangePassword(userId int, newPassword string, origin audit.Origin) (affectedRows int64, err error) {
 	if userId <= 0 {
 		return 0, errors.New("user id is required")
 	}
@@ -194,7 +234,12 @@ func (s ServiceImpl) changePassword(userId int, newPassword string) (affectedRow
 		return 0, errors.New("new password is required")
 	}
 
-	hashedPassword, err := pd.Encrypt(newPassword)
+	err = s.passwordPolicy.Validate(newPassword)
+	if err != nil {
+		return 0, err
+	}
+
+	hashedPassword, err := s.hasher.Hash(newPassword)
 	if err != nil {
 		return 0, err
 	}
@@ -208,5 +253,150 @@ func (s ServiceImpl) changePassword(userId int, newPassword string) (affectedRow
 		return 0, errors.New("no rows affected")
 	}
 
+	s.auditService.Record(audit.PASSWORD_CHANGED, userId, origin, nil)
+
 	return affectedRows, nil
 }
+
+// ResetPassword changes the password in the transaction of the reset token so a failed change does not use up the token
+func (s ServiceImpl) ResetPassword(tx *sqlx.Tx, userId int, newPassword string, origin audit.Origin) error {
+	if userId <= 0 {
+		return errors.New("user id is required")
+	}
+
+	err := s.passwordPolicy.Validate(newPassword)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	affectedRows, err := resetPassword(tx, userId, hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	if affectedRows <= 0 {
+		return errors.New("no rows affected")
+	}
+
+	s.auditService.Record(audit.PASSWORD_CHANGED, userId, origin, nil)
+
+	return nil
+}
+
+// setPassword adds a local password to an account that was created with a social login
+func (s ServiceImpl) setPassword(userId int, password string, origin audit.Origin) (affectedRows int64, err error) {
+	if userId <= 0 {
+		return 0, errors.New("user id is required")
+	}
+
+	if strings.TrimSpace(password) == "" {
+		return 0, errors.New("password is required")
+	}
+
+	err = s.passwordPolicy.Validate(password)
+	if err != nil {
+		return 0, err
+	}
+
+	hashedPassword, err := s.hasher.Hash(password)
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = s.repository.setPassword(userId, hashedPassword)
+	if err != nil {
+		return 0, err
+	}
+
+	if affectedRows <= 0 {
+		return 0, errors.New("user already has a password")
+	}
+
+	s.auditService.Record(audit.PASSWORD_SET, userId, origin, nil)
+
+	return affectedRows, nil
+}
+
+// loginFailed the user id is empty for unknown emails, the email is kept so attempts on them can still be found
+func (s ServiceImpl) loginFailed(userId int, email, reason string, origin audit.Origin) {
+	s.auditService.Record(audit.LOGIN_FAILED, userId, origin, audit.Metadata{
+		"email":  email,
+		"reason": reason,
+	})
+}
+
+func (s ServiceImpl) verifyEmail(token string) error {
+	err := s.verificationService.Verify(token)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// resendVerification returns no error for unknown and already verified users
+// so the endpoint cannot be used to check which emails are registered
+func (s ServiceImpl) resendVerification(email string) error {
+	if strings.TrimSpace(email) == "" {
+		return errors.New("email is required")
+	}
+
+	user, err := s.repository.findByEmail(email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+
+		return err
+	}
+
+	if user.IsEmailVerified() {
+		return nil
+	}
+
+	err = s.verificationService.Send(user.Id, user.Email)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s ServiceImpl) isPasswordMatch(password, hash string) bool {
+	return s.hasher.Verify(password, hash)
+}
+
+// rehashPassword upgrades a hash made with an older algorithm or cost, it can only be done at login since it needs the password
+// the password is only replaced if it was not changed since it was read
+func (s ServiceImpl) rehashPassword(user User, password string) error {
+	if !s.hasher.NeedsRehash(user.Password) {
+		return nil
+	}
+
+	hashedPassword, err := s.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.repository.rehashPassword(user.Id, user.Password, hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// isEmailVerificationRequired blocks local login of unverified users
+func isEmailVerificationRequired() bool {
+	required, err := strconv.ParseBool(os.Getenv("REQUIRE_EMAIL_VERIFICATION"))
+	if err != nil {
+		return false
+	}
+
+	return required
+}
@@ -14,4 +14,10 @@ type User struct {
 	Password   string         `json:"-" db:"password"`
 	Attachment sql.NullString `json:"attachment" db:"attachment"`
 	IsActive   bool           `json:"is_active" db:"is_active"`
+
+	EmailVerifiedAt sql.NullTime `json:"email_verified_at" db:"email_verified_at"`
+}
+
+func (u User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt.Valid
 }
@@ -0,0 +1,15 @@
+package verification
+
+import (
+	"github.com/jmoiron/sqlx"
+)
+
+// markEmailVerified runs in the transaction that uses the token so the token and the email are updated together
+func markEmailVerified(tx *sqlx.Tx, userId int) error {
+	_, err := tx.Exec("UPDATE user SET email_verified_at = NOW() WHERE id = ? AND email_verified_at IS NULL", userId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
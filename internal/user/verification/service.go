@@ -0,0 +1,74 @@
+package verification
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"social-media-application/internal/mailer"
+	ott "social-media-application/internal/one_time_token"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultExpirationInHour is used when EMAIL_VERIFICATION_EXPIRATION_IN_HOUR is not set
+const defaultExpirationInHour = 24
+
+type (
+	Service interface {
+		Send(userId int, email string) error // sends a new token and invalidates the previous ones
+		Verify(token string) error
+	}
+
+	ServiceImpl struct {
+		tokenService ott.Service
+		mailer       mailer.Mailer
+	}
+)
+
+func NewService(tokenService ott.Service, mailer mailer.Mailer) Service {
+	return &ServiceImpl{
+		tokenService: tokenService,
+		mailer:       mailer,
+	}
+}
+
+func (s ServiceImpl) Send(userId int, email string) error {
+	if userId <= 0 {
+		return errors.New("user id is required")
+	}
+
+	if strings.TrimSpace(email) == "" {
+		return errors.New("email is required")
+	}
+
+	expiration := expirationInHour()
+	token, err := s.tokenService.Issue(userId, time.Duration(expiration)*time.Hour)
+	if err != nil {
+		return err
+	}
+
+	link := os.Getenv("EMAIL_VERIFICATION_URL") + "?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf("Use the link below to verify your email address. It expires in %d hours.\n\n%s", expiration, link)
+	err = s.mailer.Send(email, "Verify your email address", body)
+	if err != nil {
+		log.Println("WARNING: sending verification email failed for user", userId, err)
+	}
+
+	return nil
+}
+
+func (s ServiceImpl) Verify(token string) error {
+	return s.tokenService.Use(token, markEmailVerified)
+}
+
+func expirationInHour() int {
+	expiration, err := strconv.Atoi(os.Getenv("EMAIL_VERIFICATION_EXPIRATION_IN_HOUR"))
+	if err != nil || expiration <= 0 {
+		return defaultExpirationInHour
+	}
+
+	return expiration
+}
@@ -0,0 +1,73 @@
+package verification
+
+import (
+	"fmt"
+	"net/url"
+	"social-media-application/internal/mailer"
+	ott "social-media-application/internal/one_time_token"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeTokenService struct {
+	ott.Service
+	issued    map[int]string
+	expiresIn time.Duration
+}
+
+func (f *fakeTokenService) Issue(userId int, expiresIn time.Duration) (string, error) {
+	token := fmt.Sprint("token-", userId)
+	f.issued[userId] = token
+	f.expiresIn = expiresIn
+	return token, nil
+}
+
+func newTestService(t *testing.T) (Service, *fakeTokenService, *mailer.MemoryMailer) {
+	t.Setenv("EMAIL_VERIFICATION_URL", "https://example.com/verify")
+	t.Setenv("EMAIL_VERIFICATION_EXPIRATION_IN_HOUR", "")
+
+	tokenService := &fakeTokenService{issued: map[int]string{}}
+	memoryMailer := mailer.NewMemoryMailer()
+	return NewService(tokenService, memoryMailer), tokenService, memoryMailer
+}
+
+func TestSendMailsTheIssuedToken(t *testing.T) {
+	service, tokenService, memoryMailer := newTestService(t)
+
+	err := service.Send(3, "a@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := memoryMailer.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+
+	if messages[0].To != "a@example.com" {
+		t.Fatalf("mail was sent to %s, want a@example.com", messages[0].To)
+	}
+
+	link := "https://example.com/verify?token=" + url.QueryEscape(tokenService.issued[3])
+	if !strings.Contains(messages[0].Body, link) {
+		t.Fatalf("mail body %q does not contain the link %s", messages[0].Body, link)
+	}
+
+	if tokenService.expiresIn != defaultExpirationInHour*time.Hour {
+		t.Fatalf("token expires in %s, want %dh", tokenService.expiresIn, defaultExpirationInHour)
+	}
+}
+
+func TestSendWithoutEmailDoesNotMail(t *testing.T) {
+	service, tokenService, memoryMailer := newTestService(t)
+
+	err := service.Send(3, " ")
+	if err == nil {
+		t.Fatal("sending without an email should fail")
+	}
+
+	if len(memoryMailer.Messages()) != 0 || len(tokenService.issued) != 0 {
+		t.Fatal("no token should be issued or mailed without an email")
+	}
+}
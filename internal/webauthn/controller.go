@@ -0,0 +1,237 @@
+package webauthn
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"social-media-application/internal/audit"
+	"social-media-application/internal/refresh"
+	"social-media-application/internal/role"
+	"social-media-application/middlewares"
+	"social-media-application/utils"
+	"strconv"
+)
+
+type (
+	Controller interface {
+		beginRegistration(ctx *gin.Context)
+		finishRegistration(ctx *gin.Context)
+
+		beginLogin(ctx *gin.Context)
+		finishLogin(ctx *gin.Context)
+
+		getAllCredentials(ctx *gin.Context)
+
+		deleteCredential(ctx *gin.Context)
+
+		RegisterRoutes(e *gin.Engine)
+	}
+
+	ControllerImpl struct {
+		service        Service
+		refreshService refresh.Service
+		roleService    role.Service
+	}
+)
+
+func NewController(service Service, refreshService refresh.Service, roleService role.Service) Controller {
+	return &ControllerImpl{
+		service:        service,
+		refreshService: refreshService,
+		roleService:    roleService,
+	}
+}
+
+func (c ControllerImpl) RegisterRoutes(e *gin.Engine) {
+	r := e.Group("/users/webauthn")
+	{
+		// Public
+		r.POST("/login/begin", c.beginLogin)
+		r.POST("/login/finish", c.finishLogin)
+
+		// Protected
+		r.POST("/register/begin", middleware.JWT, c.beginRegistration)
+		r.POST("/register/finish", middleware.JWT, c.finishRegistration)
+
+		r.GET("/credentials", middleware.JWT, c.getAllCredentials)
+		r.DELETE("/credentials/:id", middleware.JWT, c.deleteCredential)
+	}
+}
+
+// beginRegistration returns the options for navigator.credentials.create() and the session id to be sent back when finishing
+func (c ControllerImpl) beginRegistration(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "begin registration failed " + err.Error(),
+		})
+		return
+	}
+
+	options, sessionId, err := c.service.beginRegistration(sub)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "begin registration failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"session_id": sessionId,
+		"options":    options,
+	})
+}
+
+// finishRegistration body is the credential returned by navigator.credentials.create()
+func (c ControllerImpl) finishRegistration(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "finish registration failed " + err.Error(),
+		})
+		return
+	}
+
+	sessionId := ctx.Query("sessionId")
+	name := ctx.Query("name")
+	id, err := c.service.finishRegistration(sub, sessionId, name, ctx.Request.Body)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "finish registration failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, id)
+}
+
+// beginLogin returns the options for navigator.credentials.get(), email is optional
+func (c ControllerImpl) beginLogin(ctx *gin.Context) {
+	request := struct {
+		Email string `json:"email"`
+	}{}
+
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBind(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"message": "begin login failed " + err.Error(),
+			})
+			return
+		}
+	}
+
+	options, sessionId, err := c.service.beginLogin(request.Email)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "begin login failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"session_id": sessionId,
+		"options":    options,
+	})
+}
+
+// finishLogin body is the assertion returned by navigator.credentials.get()
+// sets the same cookies as the password login, the two factor challenge is skipped
+// since user verification on the authenticator is already a second factor
+func (c ControllerImpl) finishLogin(ctx *gin.Context) {
+	sessionId := ctx.Query("sessionId")
+	userId, err := c.service.finishLogin(sessionId, ctx.Request.Body)
+	if err != nil {
+		c.service.loginFailed(userId, "invalid_passkey", audit.NewOrigin(ctx))
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "login failed! " + err.Error(),
+		})
+		return
+	}
+
+	roles, permissions, err := c.roleService.GetClaims(userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "login failed! " + err.Error(),
+		})
+		return
+	}
+
+	accessToken, err := middleware.GenerateJWT(userId, roles, permissions)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "login failed! " + err.Error(),
+		})
+		return
+	}
+
+	refreshToken, err := c.refreshService.Save(userId, refresh.NewMetadata(ctx, refresh.PASSKEY))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "login failed! " + err.Error(),
+		})
+		return
+	}
+
+	err = utils.SendTokens(ctx, accessToken, refreshToken, "authentication successful")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "login failed! " + err.Error(),
+		})
+		return
+	}
+}
+
+func (c ControllerImpl) getAllCredentials(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "get all credentials failed " + err.Error(),
+		})
+		return
+	}
+
+	credentials, err := c.service.getAllCredentials(sub)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "get all credentials failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, credentials)
+}
+
+func (c ControllerImpl) deleteCredential(ctx *gin.Context) {
+	sub, err := middleware.GetSubject(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "delete credential failed " + err.Error(),
+		})
+		return
+	}
+
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "delete credential failed " + err.Error(),
+		})
+		return
+	}
+
+	_, err = c.service.deleteCredential(sub, id)
+	if err != nil {
+		if errors.Is(err, ErrLastLoginMethod) {
+			ctx.JSON(http.StatusConflict, gin.H{
+				"message": "delete credential failed " + err.Error(),
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "delete credential failed " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
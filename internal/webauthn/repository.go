@@ -0,0 +1,214 @@
+package webauthn
+
+import (
+	"database/sql"
+	"errors"
+	wa "github.com/go-webauthn/webauthn/webauthn"
+	"github.com/jmoiron/sqlx"
+	"social-media-application/internal/user"
+	"strings"
+	"time"
+)
+
+var ErrLastLoginMethod = errors.New("cannot delete the last login method, set a password or link a social account first")
+
+type (
+	Repository interface {
+		saveCredential(userId int, name string, credential *wa.Credential) (id int64, err error)
+
+		findAllCredentialsBy(userId int) ([]Credential, error)
+		findCredentialBy(credentialId []byte) (Credential, error)
+
+		updateCredentialUsage(id int, credential *wa.Credential) (affectedRows int64, err error)
+
+		deleteCredential(userId, id int) (affectedRows int64, err error)
+
+		saveSession(sessionHash, ceremony string, data []byte, userId sql.NullInt64, expiresAt time.Time) (id int64, err error)
+
+		findSession(sessionHash, ceremony string) (Session, error)
+
+		deleteSession(id int) (affectedRows int64, err error)
+	}
+
+	RepositoryImpl struct {
+		*sqlx.DB
+	}
+)
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &RepositoryImpl{
+		DB: db,
+	}
+}
+
+func (repository RepositoryImpl) saveCredential(userId int, name string, credential *wa.Credential) (id int64, err error) {
+	transports := make([]string, 0, len(credential.Transport))
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+
+	result, err := repository.NamedExec(`
+		INSERT INTO webauthn_credential (name, credential_id, public_key, attestation_type, transport, aaguid, sign_count, backup_eligible, backup_state, user_id)
+		VALUES (:name, :credentialId, :publicKey, :attestationType, :transport, :aaguid, :signCount, :backupEligible, :backupState, :userId)
+	`, map[string]any{
+		"name":            name,
+		"credentialId":    credential.ID,
+		"publicKey":       credential.PublicKey,
+		"attestationType": credential.AttestationType,
+		"transport":       strings.Join(transports, ","),
+		"aaguid":          credential.Authenticator.AAGUID,
+		"signCount":       credential.Authenticator.SignCount,
+		"backupEligible":  credential.Flags.BackupEligible,
+		"backupState":     credential.Flags.BackupState,
+		"userId":          userId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	id, err = result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (repository RepositoryImpl) findAllCredentialsBy(userId int) ([]Credential, error) {
+	credentials := make([]Credential, 0)
+	err := repository.Select(&credentials, "SELECT * FROM webauthn_credential WHERE user_id = ? ORDER BY created_at DESC", userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+func (repository RepositoryImpl) findCredentialBy(credentialId []byte) (Credential, error) {
+	var credential Credential
+	err := repository.Get(&credential, "SELECT * FROM webauthn_credential WHERE credential_id = ?", credentialId)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	return credential, nil
+}
+
+// updateCredentialUsage saves the new sign count so cloned authenticators can be detected on the next login
+func (repository RepositoryImpl) updateCredentialUsage(id int, credential *wa.Credential) (affectedRows int64, err error) {
+	result, err := repository.NamedExec(`
+		UPDATE webauthn_credential
+		SET sign_count = :signCount,
+			clone_warning = :cloneWarning,
+			backup_state = :backupState,
+			last_used_at = NOW()
+		WHERE id = :id
+	`, map[string]any{
+		"signCount":    credential.Authenticator.SignCount,
+		"cloneWarning": credential.Authenticator.CloneWarning,
+		"backupState":  credential.Flags.BackupState,
+		"id":           id,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
+func (repository RepositoryImpl) deleteCredential(userId, id int) (affectedRows int64, err error) {
+	tx, err := repository.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer func(tx *sqlx.Tx) {
+		err := tx.Rollback()
+		if err != nil {
+			return
+		}
+	}(tx)
+
+	loginMethods, err := user.LockAndCountLoginMethods(tx, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	if loginMethods <= 1 {
+		return 0, ErrLastLoginMethod
+	}
+
+	result, err := tx.Exec("DELETE FROM webauthn_credential WHERE id = ? AND user_id = ?", id, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
+// saveSession also removes expired sessions so abandoned ceremonies does not pile up
+func (repository RepositoryImpl) saveSession(sessionHash, ceremony string, data []byte, userId sql.NullInt64, expiresAt time.Time) (id int64, err error) {
+	_, err = repository.Exec("DELETE FROM webauthn_session WHERE expires_at < NOW()")
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := repository.NamedExec("INSERT INTO webauthn_session (session_hash, ceremony, data, expires_at, user_id) VALUES (:sessionHash, :ceremony, :data, :expiresAt, :userId)", map[string]any{
+		"sessionHash": sessionHash,
+		"ceremony":    ceremony,
+		"data":        data,
+		"expiresAt":   expiresAt,
+		"userId":      userId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	id, err = result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (repository RepositoryImpl) findSession(sessionHash, ceremony string) (Session, error) {
+	var session Session
+	err := repository.Get(&session, "SELECT * FROM webauthn_session WHERE session_hash = ? AND ceremony = ?", sessionHash, ceremony)
+	if err != nil {
+		return Session{}, err
+	}
+
+	return session, nil
+}
+
+// deleteSession makes a session single use, only one of two concurrent finishes can delete it
+func (repository RepositoryImpl) deleteSession(id int) (affectedRows int64, err error) {
+	result, err := repository.NamedExec("DELETE FROM webauthn_session WHERE id = :id", map[string]any{
+		"id": id,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
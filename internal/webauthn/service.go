@@ -0,0 +1,374 @@
+package webauthn
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/go-webauthn/webauthn/protocol"
+	wa "github.com/go-webauthn/webauthn/webauthn"
+	"io"
+	"os"
+	"social-media-application/internal/audit"
+	"social-media-application/internal/refresh"
+	"social-media-application/internal/user"
+	"social-media-application/utils"
+	"strings"
+	"time"
+)
+
+// ceremonyTimeout is how long the user has to interact with the authenticator
+const ceremonyTimeout = 5 * time.Minute
+
+type (
+	Service interface {
+		beginRegistration(userId int) (options *protocol.CredentialCreation, sessionId string, err error)
+		finishRegistration(userId int, sessionId, name string, body io.Reader) (id int64, err error)
+
+		beginLogin(email string) (options *protocol.CredentialAssertion, sessionId string, err error)
+		finishLogin(sessionId string, body io.Reader) (userId int, err error)
+		loginFailed(userId int, reason string, origin audit.Origin)
+
+		getAllCredentials(userId int) ([]Credential, error)
+
+		deleteCredential(userId, id int) (affectedRows int64, err error)
+	}
+
+	ServiceImpl struct {
+		repository   Repository
+		userService  user.Service
+		auditService audit.Service
+		webAuthn     *wa.WebAuthn
+	}
+)
+
+func NewService(repository Repository, userService user.Service, auditService audit.Service, webAuthn *wa.WebAuthn) Service {
+	return &ServiceImpl{
+		repository:   repository,
+		userService:  userService,
+		auditService: auditService,
+		webAuthn:     webAuthn,
+	}
+}
+
+// InitWebAuthn requires user verification so a passkey alone is enough to login
+func InitWebAuthn() (*wa.WebAuthn, error) {
+	origins := make([]string, 0)
+	for _, origin := range strings.Split(os.Getenv("WEBAUTHN_RP_ORIGINS"), ",") {
+		if strings.TrimSpace(origin) != "" {
+			origins = append(origins, strings.TrimSpace(origin))
+		}
+	}
+
+	timeout := wa.TimeoutConfig{
+		Enforce:    true,
+		Timeout:    ceremonyTimeout,
+		TimeoutUVD: ceremonyTimeout,
+	}
+
+	return wa.New(&wa.Config{
+		RPID:          os.Getenv("WEBAUTHN_RP_ID"),
+		RPDisplayName: os.Getenv("WEBAUTHN_RP_DISPLAY_NAME"),
+		RPOrigins:     origins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			RequireResidentKey: protocol.ResidentKeyRequired(),
+			ResidentKey:        protocol.ResidentKeyRequirementRequired,
+			UserVerification:   protocol.VerificationRequired,
+		},
+		Timeouts: wa.TimeoutsConfig{
+			Login:        timeout,
+			Registration: timeout,
+		},
+	})
+}
+
+func (s ServiceImpl) beginRegistration(userId int) (options *protocol.CredentialCreation, sessionId string, err error) {
+	if userId <= 0 {
+		return nil, "", errors.New("user id is required")
+	}
+
+	a, err := s.getAccount(userId)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Excluded so the same authenticator cannot be registered twice
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(a.credentials))
+	for _, credential := range a.credentials {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+
+	options, sessionData, err := s.webAuthn.BeginRegistration(a, wa.WithExclusions(exclusions))
+	if err != nil {
+		return nil, "", err
+	}
+
+	sessionId, err = s.saveSession(REGISTRATION, sessionData, sql.NullInt64{Int64: int64(userId), Valid: true})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return options, sessionId, nil
+}
+
+func (s ServiceImpl) finishRegistration(userId int, sessionId, name string, body io.Reader) (id int64, err error) {
+	if userId <= 0 {
+		return 0, errors.New("user id is required")
+	}
+
+	if strings.TrimSpace(name) == "" {
+		name = "Passkey"
+	}
+
+	if len(name) > 100 {
+		return 0, errors.New("name must be at most 100 characters")
+	}
+
+	sessionData, sessionUserId, err := s.useSession(REGISTRATION, sessionId)
+	if err != nil {
+		return 0, err
+	}
+
+	if sessionUserId != userId {
+		return 0, errors.New("session is invalid or expired")
+	}
+
+	a, err := s.getAccount(userId)
+	if err != nil {
+		return 0, err
+	}
+
+	response, err := protocol.ParseCredentialCreationResponseBody(body)
+	if err != nil {
+		return 0, err
+	}
+
+	credential, err := s.webAuthn.CreateCredential(a, sessionData, response)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err = s.repository.saveCredential(userId, name, credential)
+	if err != nil {
+		if utils.IsDuplicateEntry(err) {
+			return 0, errors.New("passkey is already registered")
+		}
+
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// beginLogin without email lets the authenticator choose from the passkeys it has for this site
+func (s ServiceImpl) beginLogin(email string) (options *protocol.CredentialAssertion, sessionId string, err error) {
+	var sessionData *wa.SessionData
+	var userId sql.NullInt64
+
+	if strings.TrimSpace(email) == "" {
+		options, sessionData, err = s.webAuthn.BeginDiscoverableLogin()
+		if err != nil {
+			return nil, "", err
+		}
+	} else {
+		u, err := s.userService.GetByEmail(email)
+		if err != nil {
+			return nil, "", errors.New("no passkey found for this user")
+		}
+
+		a, err := s.getAccount(u.Id)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if len(a.credentials) == 0 {
+			return nil, "", errors.New("no passkey found for this user")
+		}
+
+		options, sessionData, err = s.webAuthn.BeginLogin(a)
+		if err != nil {
+			return nil, "", err
+		}
+
+		userId = sql.NullInt64{Int64: int64(u.Id), Valid: true}
+	}
+
+	sessionId, err = s.saveSession(LOGIN, sessionData, userId)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return options, sessionId, nil
+}
+
+// finishLogin also returns the user id with the error once the passkey is matched to a user
+// so the failed login can be recorded against the account
+func (s ServiceImpl) finishLogin(sessionId string, body io.Reader) (userId int, err error) {
+	sessionData, sessionUserId, err := s.useSession(LOGIN, sessionId)
+	if err != nil {
+		return sessionUserId, err
+	}
+
+	response, err := protocol.ParseCredentialRequestResponseBody(body)
+	if err != nil {
+		return sessionUserId, err
+	}
+
+	var credential *wa.Credential
+	if sessionUserId > 0 {
+		userId = sessionUserId
+		a, err := s.getAccount(userId)
+		if err != nil {
+			return userId, err
+		}
+
+		credential, err = s.webAuthn.ValidateLogin(a, sessionData, response)
+		if err != nil {
+			return userId, err
+		}
+	} else {
+		credential, err = s.webAuthn.ValidateDiscoverableLogin(func(rawId, userHandle []byte) (wa.User, error) {
+			userId = userIdOf(userHandle)
+			return s.getAccount(userId)
+		}, sessionData, response)
+		if err != nil {
+			return userId, err
+		}
+	}
+
+	stored, err := s.repository.findCredentialBy(credential.ID)
+	if err != nil {
+		return userId, err
+	}
+
+	if stored.UserId != userId {
+		return userId, errors.New("passkey does not belong to this user")
+	}
+
+	_, err = s.repository.updateCredentialUsage(stored.Id, credential)
+	if err != nil {
+		return userId, err
+	}
+
+	if credential.Authenticator.CloneWarning {
+		return userId, errors.New("passkey might be cloned, please remove it and register a new one")
+	}
+
+	u, err := s.userService.GetById(userId)
+	if err != nil {
+		return userId, err
+	}
+
+	if !u.IsActive {
+		return userId, errors.New("user is deactivated")
+	}
+
+	return userId, nil
+}
+
+// loginFailed is recorded like a wrong password, the user id is empty when the passkey is unknown
+func (s ServiceImpl) loginFailed(userId int, reason string, origin audit.Origin) {
+	s.auditService.Record(audit.LOGIN_FAILED, userId, origin, audit.Metadata{
+		"login_method": refresh.PASSKEY,
+		"reason":       reason,
+	})
+}
+
+func (s ServiceImpl) getAllCredentials(userId int) ([]Credential, error) {
+	if userId <= 0 {
+		return nil, errors.New("user id is required")
+	}
+
+	credentials, err := s.repository.findAllCredentialsBy(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+func (s ServiceImpl) deleteCredential(userId, id int) (affectedRows int64, err error) {
+	if userId <= 0 {
+		return 0, errors.New("user id is required")
+	}
+
+	if id <= 0 {
+		return 0, errors.New("id is required")
+	}
+
+	affectedRows, err = s.repository.deleteCredential(userId, id)
+	if err != nil {
+		return 0, err
+	}
+
+	if affectedRows <= 0 {
+		return 0, errors.New("current user has no passkey with this id")
+	}
+
+	return affectedRows, nil
+}
+
+func (s ServiceImpl) getAccount(userId int) (account, error) {
+	u, err := s.userService.GetById(userId)
+	if err != nil {
+		return account{}, err
+	}
+
+	credentials, err := s.repository.findAllCredentialsBy(userId)
+	if err != nil {
+		return account{}, err
+	}
+
+	return newAccount(u, credentials), nil
+}
+
+// saveSession returns the session id for the client, only its hash is stored
+func (s ServiceImpl) saveSession(ceremony string, sessionData *wa.SessionData, userId sql.NullInt64) (sessionId string, err error) {
+	data, err := json.Marshal(sessionData)
+	if err != nil {
+		return "", err
+	}
+
+	sessionId, err = utils.GenerateToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = s.repository.saveSession(utils.HashToken(sessionId), ceremony, data, userId, time.Now().Add(ceremonyTimeout))
+	if err != nil {
+		return "", err
+	}
+
+	return sessionId, nil
+}
+
+// useSession deletes the session before it's validated so every challenge can only be answered once
+func (s ServiceImpl) useSession(ceremony, sessionId string) (sessionData wa.SessionData, userId int, err error) {
+	if strings.TrimSpace(sessionId) == "" {
+		return wa.SessionData{}, 0, errors.New("session id is required")
+	}
+
+	session, err := s.repository.findSession(utils.HashToken(sessionId), ceremony)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return wa.SessionData{}, 0, errors.New("session is invalid or expired")
+		}
+
+		return wa.SessionData{}, 0, err
+	}
+
+	affectedRows, err := s.repository.deleteSession(session.Id)
+	if err != nil {
+		return wa.SessionData{}, 0, err
+	}
+
+	if affectedRows <= 0 || session.IsExpired() {
+		return wa.SessionData{}, 0, errors.New("session is invalid or expired")
+	}
+
+	err = json.Unmarshal(session.Data, &sessionData)
+	if err != nil {
+		return wa.SessionData{}, 0, err
+	}
+
+	return sessionData, int(session.UserId.Int64), nil
+}
@@ -0,0 +1,127 @@
+package webauthn
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"github.com/go-webauthn/webauthn/protocol"
+	wa "github.com/go-webauthn/webauthn/webauthn"
+	"social-media-application/internal/user"
+	"strings"
+	"time"
+)
+
+const (
+	REGISTRATION = "REGISTRATION"
+	LOGIN        = "LOGIN"
+)
+
+// Credential public key is never returned, the credential id is returned so the front end can tell passkeys apart
+type Credential struct {
+	Id              int          `json:"id" db:"id"`
+	CreatedAt       time.Time    `json:"created_at" db:"created_at"`
+	Name            string       `json:"name" db:"name"`
+	CredentialId    []byte       `json:"credential_id" db:"credential_id"`
+	PublicKey       []byte       `json:"-" db:"public_key"`
+	AttestationType string       `json:"attestation_type" db:"attestation_type"`
+	Transport       string       `json:"transport" db:"transport"`
+	AAGUID          []byte       `json:"-" db:"aaguid"`
+	SignCount       uint32       `json:"-" db:"sign_count"`
+	CloneWarning    bool         `json:"clone_warning" db:"clone_warning"`
+	BackupEligible  bool         `json:"backup_eligible" db:"backup_eligible"`
+	BackupState     bool         `json:"backup_state" db:"backup_state"`
+	LastUsedAt      sql.NullTime `json:"last_used_at" db:"last_used_at"`
+	UserId          int          `json:"user_id" db:"user_id"`
+}
+
+// Session is the challenge of an unfinished ceremony, it's only stored as the sha256 of the session id given to the client
+type Session struct {
+	Id          int           `json:"id" db:"id"`
+	CreatedAt   time.Time     `json:"created_at" db:"created_at"`
+	SessionHash string        `json:"-" db:"session_hash"`
+	Ceremony    string        `json:"ceremony" db:"ceremony"`
+	Data        []byte        `json:"-" db:"data"`
+	ExpiresAt   time.Time     `json:"expires_at" db:"expires_at"`
+	UserId      sql.NullInt64 `json:"user_id" db:"user_id"`
+}
+
+func (s Session) IsExpired() bool {
+	return time.Now().After(s.ExpiresAt)
+}
+
+func (c Credential) toWebAuthn() wa.Credential {
+	transports := make([]protocol.AuthenticatorTransport, 0)
+	for _, transport := range strings.Split(c.Transport, ",") {
+		if strings.TrimSpace(transport) != "" {
+			transports = append(transports, protocol.AuthenticatorTransport(transport))
+		}
+	}
+
+	return wa.Credential{
+		ID:              c.CredentialId,
+		PublicKey:       c.PublicKey,
+		AttestationType: c.AttestationType,
+		Transport:       transports,
+		Flags: wa.CredentialFlags{
+			BackupEligible: c.BackupEligible,
+			BackupState:    c.BackupState,
+		},
+		Authenticator: wa.Authenticator{
+			AAGUID:       c.AAGUID,
+			SignCount:    c.SignCount,
+			CloneWarning: c.CloneWarning,
+		},
+	}
+}
+
+// account adapts user.User to the user expected by the webauthn library
+type account struct {
+	user        user.User
+	credentials []wa.Credential
+}
+
+func newAccount(u user.User, credentials []Credential) account {
+	webAuthnCredentials := make([]wa.Credential, 0, len(credentials))
+	for _, credential := range credentials {
+		webAuthnCredentials = append(webAuthnCredentials, credential.toWebAuthn())
+	}
+
+	return account{
+		user:        u,
+		credentials: webAuthnCredentials,
+	}
+}
+
+func (a account) WebAuthnID() []byte {
+	return userHandle(a.user.Id)
+}
+
+func (a account) WebAuthnName() string {
+	return a.user.Email
+}
+
+func (a account) WebAuthnDisplayName() string {
+	return strings.TrimSpace(a.user.FirstName + " " + a.user.LastName)
+}
+
+func (a account) WebAuthnCredentials() []wa.Credential {
+	return a.credentials
+}
+
+func (a account) WebAuthnIcon() string {
+	return ""
+}
+
+// userHandle is the user id in 8 bytes, it's what discoverable passkeys return to identify the user
+func userHandle(userId int) []byte {
+	handle := make([]byte, 8)
+	binary.BigEndian.PutUint64(handle, uint64(userId))
+	return handle
+}
+
+func userIdOf(handle []byte) int {
+	if len(handle) != 8 {
+		return 0
+	}
+
+	return int(binary.BigEndian.Uint64(handle))
+}
@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// HasPermission must be used after JWT
+func HasPermission(permission string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		permissions, err := GetPermissions(ctx)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+
+		if !slices.Contains(permissions, permission) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"message": "missing permission " + permission,
+			})
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// OwnerOrPermission lets the request through when the path param is the current user id
+// or when the current user has the permission. Must be used after JWT
+func OwnerOrPermission(param, permission string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		sub, err := GetSubject(ctx)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+
+		id, err := strconv.Atoi(ctx.Param(param))
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"message": param + " is not a number",
+			})
+			return
+		}
+
+		if id == sub {
+			ctx.Next()
+			return
+		}
+
+		permissions, err := GetPermissions(ctx)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+
+		if !slices.Contains(permissions, permission) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"message": "current user is not the owner and missing permission " + permission,
+			})
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+func GetRoles(ctx *gin.Context) ([]string, error) {
+	return getStrings(ctx, "roles")
+}
+
+func GetPermissions(ctx *gin.Context) ([]string, error) {
+	return getStrings(ctx, "permissions")
+}
+
+// getStrings converts the []any of the parsed claims
+func getStrings(ctx *gin.Context, key string) ([]string, error) {
+	value, exists := ctx.Get(key)
+	if !exists {
+		return nil, errors.New(key + " not found")
+	}
+
+	if value == nil {
+		return []string{}, nil
+	}
+
+	values, ok := value.([]any)
+	if !ok {
+		return nil, errors.New(key + " is not a list")
+	}
+
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			return nil, errors.New(key + " contains non string value")
+		}
+
+		result = append(result, s)
+	}
+
+	return result, nil
+}
@@ -7,29 +7,37 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// challengeType marks the short-lived token issued after the password step of a two factor login
+// it only proves the first factor so it must never be accepted as an access token
+const (
+	challengeType               = "2fa_challenge"
+	challengeExpirationInMinute = 5
+)
+
 // authenticationHeader = Authorization: Bearer <jwt>
 // tokenString referred as the jwt but its not validated yet
 // token referred as the jwt and its validated
 
+// JWT accepts the Authorization header for non-browser clients and the accessToken cookie for browsers
 func JWT(ctx *gin.Context) {
-	accessToken, err := ctx.Cookie("accessToken")
-	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"message": "no logged in user",
-		})
-		return
+	accessToken, ok := getBearerToken(ctx)
+	if !ok {
+		cookie, err := ctx.Cookie("accessToken")
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"message": "no logged in user",
+			})
+			return
+		}
+		accessToken = cookie
 	}
 
-	// Validate signing method and return secret key and check for expiration
-	token, err := jwt.Parse(accessToken, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return getSecretKey(), nil
-	})
+	// Validate signing method by kid and check for expiration, issuer, and audience
+	token, err := parse(accessToken, jwt.WithAudience(os.Getenv("JWT_AUDIENCE")))
 	if err != nil {
 		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 			"message": err.Error(),
@@ -38,12 +46,14 @@ func JWT(ctx *gin.Context) {
 	}
 
 	// Parse all claims
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid && claims["typ"] != challengeType {
 		ctx.Set("sub", claims["sub"])
 		ctx.Set("iat", claims["iat"])
 		ctx.Set("exp", claims["exp"])
 		ctx.Set("iss", claims["iss"])
 		ctx.Set("aud", claims["aud"])
+		ctx.Set("roles", claims["roles"])
+		ctx.Set("permissions", claims["permissions"])
 	} else {
 		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 			"message": "invalid claims",
@@ -54,20 +64,29 @@ func JWT(ctx *gin.Context) {
 	ctx.Next()
 }
 
-func GenerateJWT(id int) (string, error) {
+// GenerateJWT also carries the roles and permissions of the user
+// so changes only take effect on the next login or refresh
+func GenerateJWT(id int, roles, permissions []string) (string, error) {
 	expirationInMinute, err := strconv.Atoi(os.Getenv("JWT_EXPIRATION_IN_MINUTE"))
 	if err != nil {
 		return "", err
 	}
 
+	k, err := getKeyring()
+	if err != nil {
+		return "", err
+	}
+
 	now := time.Now()
-	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": id,
-		"iat": now,
-		"exp": now.Add(time.Duration(expirationInMinute) * time.Minute).Unix(),
-		"iss": os.Getenv("JWT_ISSUER"),
-		"aud": os.Getenv("JWT_AUDIENCE"),
-	}).SignedString(getSecretKey())
+	token, err := k.sign(jwt.MapClaims{
+		"sub":         id,
+		"iat":         now.Unix(),
+		"exp":         now.Add(time.Duration(expirationInMinute) * time.Minute).Unix(),
+		"iss":         os.Getenv("JWT_ISSUER"),
+		"aud":         os.Getenv("JWT_AUDIENCE"),
+		"roles":       roles,
+		"permissions": permissions,
+	})
 
 	if err != nil {
 		return "", err
@@ -76,6 +95,80 @@ func GenerateJWT(id int) (string, error) {
 	return token, nil
 }
 
+// Challenge is the first factor of a two factor login
+type Challenge struct {
+	UserId      int
+	IssuedAt    time.Time
+	LoginMethod string // the first factor like password or a social provider, saved in the session once the code is verified
+}
+
+func GenerateChallenge(id int, loginMethod string) (string, error) {
+	k, err := getKeyring()
+	if err != nil {
+		return "", err
+	}
+
+	// No audience so it's never accepted as an access token by other services
+	now := time.Now()
+	challenge, err := k.sign(jwt.MapClaims{
+		"sub":          id,
+		"typ":          challengeType,
+		"iat":          now.Unix(),
+		"exp":          now.Add(challengeExpirationInMinute * time.Minute).Unix(),
+		"iss":          os.Getenv("JWT_ISSUER"),
+		"login_method": loginMethod,
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return challenge, nil
+}
+
+// ParseChallenge returns a valid and unexpired challenge
+func ParseChallenge(challenge string) (Challenge, error) {
+	token, err := parse(challenge)
+	if err != nil {
+		return Challenge{}, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid || claims["typ"] != challengeType {
+		return Challenge{}, errors.New("invalid challenge")
+	}
+
+	sub, ok := claims["sub"].(float64)
+	if !ok {
+		return Challenge{}, errors.New("id is not a number")
+	}
+
+	iat, ok := claims["iat"].(float64)
+	if !ok {
+		return Challenge{}, errors.New("invalid challenge")
+	}
+
+	loginMethod, ok := claims["login_method"].(string)
+	if !ok || strings.TrimSpace(loginMethod) == "" {
+		return Challenge{}, errors.New("invalid challenge")
+	}
+
+	return Challenge{
+		UserId:      int(sub),
+		IssuedAt:    time.Unix(int64(iat), 0),
+		LoginMethod: loginMethod,
+	}, nil
+}
+
+func getBearerToken(ctx *gin.Context) (string, bool) {
+	scheme, tokenString, ok := strings.Cut(ctx.GetHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(tokenString) == "" {
+		return "", false
+	}
+
+	return strings.TrimSpace(tokenString), true
+}
+
 func GetSubject(ctx *gin.Context) (int, error) {
 	sub, exists := ctx.Get("sub")
 	if !exists {
@@ -104,6 +197,17 @@ func GetExpiration(ctx *gin.Context) (time.Time, error) {
 	return time.Unix(int64(expiration), 0), nil
 }
 
-func getSecretKey() []byte {
-	return []byte(os.Getenv("JWT_SECRET_KEY"))
+// parse verifies the signature with the keyring and always requires the issuer and expiration
+func parse(tokenString string, opts ...jwt.ParserOption) (*jwt.Token, error) {
+	k, err := getKeyring()
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts,
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(os.Getenv("JWT_ISSUER")),
+		jwt.WithExpirationRequired(),
+	)
+	return jwt.Parse(tokenString, k.keyFunc, opts...)
 }
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"testing"
+)
+
+func initTestKeyring(t *testing.T) {
+	t.Setenv("JWT_ISSUER", "test-issuer")
+	t.Setenv("JWT_AUDIENCE", "test-audience")
+	t.Setenv("JWT_EXPIRATION_IN_MINUTE", "15")
+	t.Setenv("JWT_PRIVATE_KEY_PATH", "")
+
+	err := InitKeyring()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChallengeCarriesTheLoginMethod(t *testing.T) {
+	initTestKeyring(t)
+
+	challenge, err := GenerateChallenge(7, "social:google")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := ParseChallenge(challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.UserId != 7 || parsed.LoginMethod != "social:google" || parsed.IssuedAt.IsZero() {
+		t.Fatalf("got %+v, want user 7 logged in with social:google", parsed)
+	}
+}
+
+func TestAccessTokenIsNotAChallenge(t *testing.T) {
+	initTestKeyring(t)
+
+	accessToken, err := GenerateJWT(7, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ParseChallenge(accessToken)
+	if err == nil {
+		t.Fatal("an access token should not be accepted as a challenge")
+	}
+}
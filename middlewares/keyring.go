@@ -0,0 +1,261 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// signingKey the kid is the RFC 7638 thumbprint of the public key so it never has to be configured
+type signingKey struct {
+	kid       string
+	method    jwt.SigningMethod
+	private   crypto.Signer
+	publicKey crypto.PublicKey
+}
+
+// keySet signs with the current key and still verifies tokens signed by the previous keys
+// so rotating the key does not log everyone out
+type keySet struct {
+	current *signingKey
+	keys    map[string]*signingKey
+}
+
+var keyring *keySet
+
+// InitKeyring loads JWT_PRIVATE_KEY_PATH as the signing key and JWT_PREVIOUS_KEY_PATHS as verification only keys
+// RSA keys are signed with RS256 and Ed25519 keys with EdDSA
+// the temporary key used without JWT_PRIVATE_KEY_PATH is only allowed in debug mode
+func InitKeyring() error {
+	if strings.TrimSpace(os.Getenv("JWT_ISSUER")) == "" || strings.TrimSpace(os.Getenv("JWT_AUDIENCE")) == "" {
+		return errors.New("JWT_ISSUER and JWT_AUDIENCE are required")
+	}
+
+	k := &keySet{
+		keys: make(map[string]*signingKey),
+	}
+
+	path := strings.TrimSpace(os.Getenv("JWT_PRIVATE_KEY_PATH"))
+	if path == "" && gin.Mode() == gin.ReleaseMode {
+		return errors.New("JWT_PRIVATE_KEY_PATH is required in release mode, every instance has to sign with the same key")
+	}
+
+	if path == "" {
+		log.Println("WARNING: JWT_PRIVATE_KEY_PATH is not set! tokens are signed with a temporary key and are invalid after restart")
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return err
+		}
+
+		key, err := newSigningKey(private, private.Public())
+		if err != nil {
+			return err
+		}
+
+		k.current = key
+	} else {
+		key, err := loadKey(path)
+		if err != nil {
+			return err
+		}
+
+		if key.private == nil {
+			return errors.New("JWT_PRIVATE_KEY_PATH should be a private key")
+		}
+
+		k.current = key
+	}
+	k.keys[k.current.kid] = k.current
+
+	for _, path := range strings.Split(os.Getenv("JWT_PREVIOUS_KEY_PATHS"), ",") {
+		if strings.TrimSpace(path) == "" {
+			continue
+		}
+
+		key, err := loadKey(strings.TrimSpace(path))
+		if err != nil {
+			return err
+		}
+
+		// Previous keys are only used to verify
+		key.private = nil
+		if _, exists := k.keys[key.kid]; !exists {
+			k.keys[key.kid] = key
+		}
+	}
+
+	keyring = k
+	return nil
+}
+
+// JWKS publishes the public keys so other services can verify the tokens without the private key
+func JWKS(ctx *gin.Context) {
+	if keyring == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "keyring is not initialized",
+		})
+		return
+	}
+
+	keys := make([]map[string]string, 0, len(keyring.keys))
+	keys = append(keys, keyring.current.jwk())
+	for kid, key := range keyring.keys {
+		if kid == keyring.current.kid {
+			continue
+		}
+		keys = append(keys, key.jwk())
+	}
+
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, gin.H{
+		"keys": keys,
+	})
+}
+
+func (k *keySet) sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(k.current.method, claims)
+	token.Header["kid"] = k.current.kid
+	return token.SignedString(k.current.private)
+}
+
+// keyFunc picks the verification key by kid and rejects an algorithm that does not belong to it
+func (k *keySet) keyFunc(token *jwt.Token) (any, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		return nil, errors.New("missing kid")
+	}
+
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, errors.New("unknown kid")
+	}
+
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+
+	return key.publicKey, nil
+}
+
+func getKeyring() (*keySet, error) {
+	if keyring == nil {
+		return nil, errors.New("keyring is not initialized")
+	}
+
+	return keyring, nil
+}
+
+// loadKey accepts a PKCS8 or PKCS1 private key, or a PKIX public key for previous keys
+func loadKey(path string) (*signingKey, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(bytes)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM file", path)
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		signer, ok := private.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a signing key", path)
+		}
+
+		return newSigningKey(signer, signer.Public())
+	case "RSA PRIVATE KEY":
+		private, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		return newSigningKey(private, private.Public())
+	case "PUBLIC KEY":
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		return newSigningKey(nil, public)
+	default:
+		return nil, fmt.Errorf("%s has an unsupported PEM type %s", path, block.Type)
+	}
+}
+
+func newSigningKey(private crypto.Signer, public crypto.PublicKey) (*signingKey, error) {
+	key := &signingKey{
+		private:   private,
+		publicKey: public,
+	}
+
+	switch public := public.(type) {
+	case *rsa.PublicKey:
+		if public.N.BitLen() < 2048 {
+			return nil, errors.New("rsa key should be at least 2048 bits")
+		}
+		key.method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, errors.New("only rsa and ed25519 keys are supported")
+	}
+
+	thumbprint, err := json.Marshal(key.thumbprintMembers())
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(thumbprint)
+	key.kid = base64.RawURLEncoding.EncodeToString(sum[:])
+	return key, nil
+}
+
+// thumbprintMembers are the required members of the JWK, json.Marshal sorts them like RFC 7638 requires
+func (key *signingKey) thumbprintMembers() map[string]string {
+	switch public := key.publicKey.(type) {
+	case *rsa.PublicKey:
+		return map[string]string{
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			"kty": "RSA",
+			"n":   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+		}
+	case ed25519.PublicKey:
+		return map[string]string{
+			"crv": "Ed25519",
+			"kty": "OKP",
+			"x":   base64.RawURLEncoding.EncodeToString(public),
+		}
+	default:
+		return nil
+	}
+}
+
+func (key *signingKey) jwk() map[string]string {
+	jwk := key.thumbprintMembers()
+	jwk["kid"] = key.kid
+	jwk["alg"] = key.method.Alg()
+	jwk["use"] = "sig"
+	return jwk
+}
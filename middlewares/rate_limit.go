@@ -0,0 +1,214 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	RateLimitPolicy struct {
+		Name   string
+		Limit  int
+		Window time.Duration
+		// PerIp ignores the logged-in user, it's used by login and other routes that can be brute forced
+		PerIp bool
+	}
+
+	RateLimitResult struct {
+		Allowed    bool
+		Remaining  int
+		Reset      time.Duration // until the current window ends
+		RetryAfter time.Duration // only set when not allowed
+	}
+
+	// RateLimitStore counts requests with a sliding window
+	// the memory store only works for a single instance, use redis for multi instance deployments
+	RateLimitStore interface {
+		Allow(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error)
+	}
+
+	rateLimitRule struct {
+		method   string
+		path     string
+		isPrefix bool
+		policy   RateLimitPolicy
+	}
+
+	// RateLimiter picks the policy by route, the first matching rule wins
+	RateLimiter struct {
+		store         RateLimitStore
+		defaultPolicy RateLimitPolicy
+		rules         []rateLimitRule
+	}
+)
+
+// NewRateLimitStore returns the store selected by RATE_LIMIT_DRIVER, either memory (default) or redis
+func NewRateLimitStore(driver string) RateLimitStore {
+	if driver == "redis" {
+		return NewRedisRateLimitStore(os.Getenv("REDIS_ADDR"), os.Getenv("REDIS_PASSWORD"))
+	}
+
+	return NewMemoryRateLimitStore()
+}
+
+func NewRateLimiter(store RateLimitStore, defaultPolicy RateLimitPolicy) *RateLimiter {
+	return &RateLimiter{
+		store:         store,
+		defaultPolicy: defaultPolicy,
+	}
+}
+
+// Route applies the policy to a single route, path is the route pattern like /users/posts/:id
+func (l *RateLimiter) Route(method, path string, policy RateLimitPolicy) *RateLimiter {
+	l.rules = append(l.rules, rateLimitRule{
+		method: method,
+		path:   path,
+		policy: policy,
+	})
+	return l
+}
+
+// Group applies the policy to every route that starts with the prefix
+func (l *RateLimiter) Group(prefix string, policy RateLimitPolicy) *RateLimiter {
+	l.rules = append(l.rules, rateLimitRule{
+		path:     prefix,
+		isPrefix: true,
+		policy:   policy,
+	})
+	return l
+}
+
+// Handler must be used before the routes are registered
+// the store failing lets the request through so redis being down does not take the API down
+func (l *RateLimiter) Handler(ctx *gin.Context) {
+	if ctx.Request.Method == http.MethodOptions {
+		ctx.Next()
+		return
+	}
+
+	policy := l.policyOf(ctx.Request.Method, ctx.FullPath())
+	key := policy.Name + ":" + rateLimitKey(ctx, policy)
+
+	result, err := l.store.Allow(ctx.Request.Context(), key, policy)
+	if err != nil {
+		log.Println("WARNING: rate limit store failed, request is allowed", err)
+		ctx.Next()
+		return
+	}
+
+	ctx.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, int(policy.Window.Seconds())))
+	ctx.Header("RateLimit-Limit", strconv.Itoa(policy.Limit))
+	ctx.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	ctx.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+	if !result.Allowed {
+		ctx.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+		ctx.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+			"message": "too many requests, try again later",
+		})
+		return
+	}
+
+	ctx.Next()
+}
+
+func (l *RateLimiter) policyOf(method, path string) RateLimitPolicy {
+	for _, rule := range l.rules {
+		if rule.isPrefix && strings.HasPrefix(path, rule.path) {
+			return rule.policy
+		}
+
+		if !rule.isPrefix && rule.method == method && rule.path == path {
+			return rule.policy
+		}
+	}
+
+	return l.defaultPolicy
+}
+
+// rateLimitKey is the user id when the access token or personal access token is valid, otherwise the ip
+// the rate limiter runs before JWT so the token is checked here without rejecting the request
+// an invalid token is never used as the key so sending random tokens cannot get a new bucket each time
+func rateLimitKey(ctx *gin.Context, policy RateLimitPolicy) string {
+	ip := "ip:" + ctx.ClientIP()
+	if policy.PerIp {
+		return ip
+	}
+
+	tokenString, ok := getBearerToken(ctx)
+	if ok && strings.HasPrefix(tokenString, PersonalAccessTokenPrefix) {
+		userId, _, err := authenticatePersonalAccessToken(ctx, tokenString)
+		if err != nil {
+			return ip
+		}
+
+		return "user:" + strconv.Itoa(userId)
+	}
+
+	if !ok {
+		tokenString, _ = ctx.Cookie("accessToken")
+	}
+
+	if tokenString != "" {
+		token, err := parse(tokenString, jwt.WithAudience(os.Getenv("JWT_AUDIENCE")))
+		if err == nil {
+			if claims, ok := token.Claims.(jwt.MapClaims); ok && claims["typ"] != challengeType {
+				if sub, ok := claims["sub"].(float64); ok {
+					return "user:" + strconv.Itoa(int(sub))
+				}
+			}
+		}
+	}
+
+	return ip
+}
+
+// slidingWindow estimates the requests in the last window by weighting the previous window
+// with how much of it still overlaps, both stores use it so they make the same decisions
+func slidingWindow(previous, current int, elapsed time.Duration, policy RateLimitPolicy) RateLimitResult {
+	weight := 1 - float64(elapsed)/float64(policy.Window)
+	estimated := float64(previous)*weight + float64(current)
+	reset := policy.Window - elapsed
+
+	if estimated+1 > float64(policy.Limit) {
+		// Wait until the current window ends or until enough of the previous window slides out
+		retryAfter := reset
+		if current < policy.Limit && previous > 0 {
+			excess := estimated + 1 - float64(policy.Limit)
+			retryAfter = time.Duration(excess / float64(previous) * float64(policy.Window))
+		}
+
+		return RateLimitResult{
+			Allowed:    false,
+			Remaining:  0,
+			Reset:      reset,
+			RetryAfter: retryAfter,
+		}
+	}
+
+	return RateLimitResult{
+		Allowed:   true,
+		Remaining: int(math.Floor(float64(policy.Limit) - estimated - 1)),
+		Reset:     reset,
+	}
+}
+
+// windowOf returns the index of the current window and how much of it has elapsed
+func windowOf(now time.Time, window time.Duration) (index int64, elapsed time.Duration) {
+	index = now.UnixNano() / int64(window)
+	elapsed = time.Duration(now.UnixNano() - index*int64(window))
+	return index, elapsed
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type (
+	rateLimitCounter struct {
+		index    int64
+		previous int
+		current  int
+		window   time.Duration
+	}
+
+	MemoryRateLimitStore struct {
+		mu          sync.Mutex
+		counters    map[string]*rateLimitCounter
+		lastPurgeAt time.Time
+	}
+)
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		counters: make(map[string]*rateLimitCounter),
+	}
+}
+
+func (m *MemoryRateLimitStore) Allow(_ context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.purge(now)
+
+	index, elapsed := windowOf(now, policy.Window)
+	counter, ok := m.counters[key]
+	if !ok {
+		counter = &rateLimitCounter{index: index, window: policy.Window}
+		m.counters[key] = counter
+	}
+
+	// Slide the windows forward, anything older than the previous window no longer counts
+	if counter.index != index {
+		if counter.index == index-1 {
+			counter.previous = counter.current
+		} else {
+			counter.previous = 0
+		}
+		counter.current = 0
+		counter.index = index
+	}
+
+	result := slidingWindow(counter.previous, counter.current, elapsed, policy)
+	if result.Allowed {
+		counter.current++
+	}
+
+	return result, nil
+}
+
+// purge removes counters that no longer affect any decision at most once a minute
+func (m *MemoryRateLimitStore) purge(now time.Time) {
+	if now.Sub(m.lastPurgeAt) < time.Minute {
+		return
+	}
+	m.lastPurgeAt = now
+
+	for key, counter := range m.counters {
+		index, _ := windowOf(now, counter.window)
+		if counter.index < index-1 {
+			delete(m.counters, key)
+		}
+	}
+}
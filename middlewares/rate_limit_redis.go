@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+// slidingWindowScript reads both windows and only counts the request when it's allowed
+// so the check and the increment cannot race between instances
+var slidingWindowScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+local previous = tonumber(redis.call('GET', KEYS[2]) or '0')
+if previous * tonumber(ARGV[1]) + current + 1 > tonumber(ARGV[2]) then
+	return {current, previous}
+end
+if redis.call('INCR', KEYS[1]) == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[3])
+end
+return {current, previous}
+`)
+
+// RedisRateLimitStore works with anything that speaks the redis protocol
+type RedisRateLimitStore struct {
+	client *redis.Client
+}
+
+func NewRedisRateLimitStore(addr, password string) *RedisRateLimitStore {
+	return &RedisRateLimitStore{
+		client: redis.NewClient(&redis.Options{
+			Addr:     addr,
+			Password: password,
+		}),
+	}
+}
+
+func (r *RedisRateLimitStore) Allow(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error) {
+	index, elapsed := windowOf(time.Now(), policy.Window)
+	weight := 1 - float64(elapsed)/float64(policy.Window)
+
+	keys := []string{
+		fmt.Sprintf("ratelimit:%s:%d", key, index),
+		fmt.Sprintf("ratelimit:%s:%d", key, index-1),
+	}
+	args := []any{
+		strconv.FormatFloat(weight, 'f', 6, 64),
+		policy.Limit,
+		// Kept for 2 windows since it becomes the previous window
+		(2 * policy.Window).Milliseconds(),
+	}
+
+	counts, err := slidingWindowScript.Run(ctx, r.client, keys, args...).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	if len(counts) != 2 {
+		return RateLimitResult{}, fmt.Errorf("unexpected rate limit script result %v", counts)
+	}
+
+	return slidingWindow(int(counts[1]), int(counts[0]), elapsed, policy), nil
+}
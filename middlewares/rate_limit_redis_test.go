@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// The window is long enough that the test never crosses into the next one
+var testPolicy = RateLimitPolicy{Name: "test", Limit: 3, Window: time.Hour}
+
+func newTestRedisStore(t *testing.T) (*RedisRateLimitStore, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	return NewRedisRateLimitStore(server.Addr(), ""), server
+}
+
+// Both stores should make the same decisions so switching RATE_LIMIT_DRIVER does not change the limits
+func TestRateLimitStores(t *testing.T) {
+	redisStore, _ := newTestRedisStore(t)
+	stores := map[string]RateLimitStore{
+		"memory": NewMemoryRateLimitStore(),
+		"redis":  redisStore,
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			for i, remaining := range []int{2, 1, 0} {
+				result, err := store.Allow(ctx, "user:1", testPolicy)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !result.Allowed {
+					t.Fatalf("request %d should be allowed", i+1)
+				}
+
+				if result.Remaining != remaining {
+					t.Fatalf("request %d remaining is %d, want %d", i+1, result.Remaining, remaining)
+				}
+			}
+
+			result, err := store.Allow(ctx, "user:1", testPolicy)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.Allowed || result.Remaining != 0 || result.RetryAfter <= 0 {
+				t.Fatalf("request over the limit should be denied with a retry after, got %+v", result)
+			}
+
+			// Denied requests are not counted so they do not push the retry further away
+			again, err := store.Allow(ctx, "user:1", testPolicy)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if again.Allowed {
+				t.Fatal("request over the limit should still be denied")
+			}
+
+			other, err := store.Allow(ctx, "user:2", testPolicy)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !other.Allowed || other.Remaining != 2 {
+				t.Fatalf("other keys should have their own bucket, got %+v", other)
+			}
+		})
+	}
+}
+
+func TestRedisRateLimitStoreWeightsPreviousWindow(t *testing.T) {
+	store, server := newTestRedisStore(t)
+	ctx := context.Background()
+
+	index, elapsed := windowOf(time.Now(), testPolicy.Window)
+	weight := 1 - float64(elapsed)/float64(testPolicy.Window)
+
+	// A full previous window only lets requests through once enough of it has slid out
+	previous := fmt.Sprintf("ratelimit:%s:%d", "user:1", index-1)
+	server.Set(previous, "3")
+
+	result, err := store.Allow(ctx, "user:1", testPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := slidingWindow(3, 0, elapsed, testPolicy)
+	if result.Allowed != want.Allowed {
+		t.Fatalf("allowed is %v, want %v with weight %f", result.Allowed, want.Allowed, weight)
+	}
+}
+
+func TestRedisRateLimitStoreExpiresCounters(t *testing.T) {
+	store, server := newTestRedisStore(t)
+
+	_, err := store.Allow(context.Background(), "user:1", testPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(server.Keys()) != 1 {
+		t.Fatalf("keys are %v, want a single counter", server.Keys())
+	}
+
+	// Kept for 2 windows since the counter is still read as the previous window
+	server.FastForward(2*testPolicy.Window + time.Second)
+	if len(server.Keys()) != 0 {
+		t.Fatalf("keys are %v, want the counter to expire", server.Keys())
+	}
+}
+
+func TestRedisRateLimitStoreDown(t *testing.T) {
+	store, server := newTestRedisStore(t)
+	server.Close()
+
+	_, err := store.Allow(context.Background(), "user:1", testPolicy)
+	if err == nil {
+		t.Fatal("an unreachable redis should return an error so the handler can let the request through")
+	}
+}
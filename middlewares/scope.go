@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// PersonalAccessTokenPrefix makes personal access tokens easy to tell apart from access tokens and to find in leaked code
+const PersonalAccessTokenPrefix = "sma_pat_"
+
+// PersonalAccessTokenAuthenticator is implemented by the personal access token module
+type PersonalAccessTokenAuthenticator interface {
+	Authenticate(token string) (userId int, scopes []string, err error)
+}
+
+// authenticatedToken is kept in the context so the rate limiter and Scoped only look up the token once
+type authenticatedToken struct {
+	token  string
+	userId int
+	scopes []string
+}
+
+const authenticatedTokenKey = "authenticatedToken"
+
+var personalAccessTokens PersonalAccessTokenAuthenticator
+
+func SetPersonalAccessTokenAuthenticator(authenticator PersonalAccessTokenAuthenticator) {
+	personalAccessTokens = authenticator
+}
+
+// Scoped is used instead of JWT on route groups that also accept personal access tokens
+// a personal access token needs <resource>:read for GET requests and <resource>:write for everything else
+// logged-in users are not limited by scopes
+func Scoped(resource string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// Access tokens are always a JWT with 3 parts
+		token, ok := getBearerToken(ctx)
+		if !ok || strings.Count(token, ".") == 2 {
+			JWT(ctx)
+			return
+		}
+
+		userId, scopes, err := authenticatePersonalAccessToken(ctx, token)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+
+		scope := resource + ":write"
+		if ctx.Request.Method == http.MethodGet || ctx.Request.Method == http.MethodHead {
+			scope = resource + ":read"
+		}
+
+		if !slices.Contains(scopes, scope) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"message": "missing scope " + scope,
+			})
+			return
+		}
+
+		// Same keys as JWT so GetSubject works, there are no roles and permissions
+		ctx.Set("sub", float64(userId))
+		ctx.Set("scopes", scopes)
+		ctx.Next()
+	}
+}
+
+func authenticatePersonalAccessToken(ctx *gin.Context, token string) (userId int, scopes []string, err error) {
+	if value, exists := ctx.Get(authenticatedTokenKey); exists {
+		if authenticated, ok := value.(authenticatedToken); ok && authenticated.token == token {
+			return authenticated.userId, authenticated.scopes, nil
+		}
+	}
+
+	if personalAccessTokens == nil {
+		return 0, nil, errors.New("personal access tokens are not supported")
+	}
+
+	userId, scopes, err = personalAccessTokens.Authenticate(token)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	ctx.Set(authenticatedTokenKey, authenticatedToken{
+		token:  token,
+		userId: userId,
+		scopes: scopes,
+	})
+	return userId, scopes, nil
+}
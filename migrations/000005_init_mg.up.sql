@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS follow (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    created_at DATETIME NOT NULL DEFAULT NOW(),
+
+    follower_id BIGINT UNSIGNED NOT NULL,
+    followee_id BIGINT UNSIGNED NOT NULL,
+    FOREIGN KEY (follower_id) REFERENCES user(id),
+    FOREIGN KEY (followee_id) REFERENCES user(id)
+);
+
+CREATE UNIQUE INDEX idx_follower_followee ON follow(follower_id, followee_id);
+CREATE INDEX idx_followee ON follow(followee_id);
+CREATE INDEX idx_created_at ON follow(created_at);
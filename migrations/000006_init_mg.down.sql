@@ -0,0 +1,3 @@
+DROP INDEX idx_author_created_at ON post;
+
+DROP TABLE IF EXISTS timeline;
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS timeline (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    created_at DATETIME NOT NULL,
+
+    user_id BIGINT UNSIGNED NOT NULL,
+    post_id BIGINT UNSIGNED NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES user(id),
+    FOREIGN KEY (post_id) REFERENCES post(id)
+);
+
+CREATE UNIQUE INDEX idx_user_post ON timeline(user_id, post_id);
+CREATE INDEX idx_user_created_at_post ON timeline(user_id, created_at, post_id);
+
+CREATE INDEX idx_author_created_at ON post(author_id, created_at, id);
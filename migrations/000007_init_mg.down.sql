@@ -0,0 +1 @@
+DROP TABLE IF EXISTS friendship;
\ No newline at end of file
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS friendship (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    created_at DATETIME NOT NULL DEFAULT NOW(),
+    updated_at DATETIME NOT NULL DEFAULT NOW() ON UPDATE NOW(),
+    status ENUM('PENDING', 'ACCEPTED', 'DECLINED') NOT NULL DEFAULT 'PENDING',
+
+    requester_id BIGINT UNSIGNED NOT NULL,
+    addressee_id BIGINT UNSIGNED NOT NULL,
+    FOREIGN KEY (requester_id) REFERENCES user(id),
+    FOREIGN KEY (addressee_id) REFERENCES user(id)
+);
+
+CREATE UNIQUE INDEX idx_requester_addressee ON friendship(requester_id, addressee_id);
+CREATE INDEX idx_addressee_status ON friendship(addressee_id, status);
+CREATE INDEX idx_created_at ON friendship(created_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS mute;
+DROP TABLE IF EXISTS block;
\ No newline at end of file
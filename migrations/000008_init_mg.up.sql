@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS block (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    created_at DATETIME NOT NULL DEFAULT NOW(),
+
+    blocker_id BIGINT UNSIGNED NOT NULL,
+    blocked_id BIGINT UNSIGNED NOT NULL,
+    FOREIGN KEY (blocker_id) REFERENCES user(id),
+    FOREIGN KEY (blocked_id) REFERENCES user(id)
+);
+
+CREATE UNIQUE INDEX idx_blocker_blocked ON block(blocker_id, blocked_id);
+CREATE INDEX idx_blocked ON block(blocked_id);
+
+CREATE TABLE IF NOT EXISTS mute (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    created_at DATETIME NOT NULL DEFAULT NOW(),
+
+    muter_id BIGINT UNSIGNED NOT NULL,
+    muted_id BIGINT UNSIGNED NOT NULL,
+    FOREIGN KEY (muter_id) REFERENCES user(id),
+    FOREIGN KEY (muted_id) REFERENCES user(id)
+);
+
+CREATE UNIQUE INDEX idx_muter_muted ON mute(muter_id, muted_id);
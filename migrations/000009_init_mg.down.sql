@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS post_audience;
+
+ALTER TABLE post DROP COLUMN visibility;
\ No newline at end of file
@@ -0,0 +1,12 @@
+ALTER TABLE post ADD COLUMN visibility ENUM('PUBLIC', 'FOLLOWERS', 'FRIENDS', 'ONLY_ME', 'CUSTOM') NOT NULL DEFAULT 'PUBLIC';
+
+CREATE TABLE IF NOT EXISTS post_audience (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+
+    post_id BIGINT UNSIGNED NOT NULL,
+    user_id BIGINT UNSIGNED NOT NULL,
+    FOREIGN KEY (post_id) REFERENCES post(id),
+    FOREIGN KEY (user_id) REFERENCES user(id)
+);
+
+CREATE UNIQUE INDEX idx_post_user ON post_audience(post_id, user_id);
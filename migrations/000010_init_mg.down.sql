@@ -0,0 +1,7 @@
+ALTER TABLE comment DROP FOREIGN KEY fk_comment_parent;
+
+DROP INDEX idx_parent_created_at ON comment;
+
+ALTER TABLE comment
+    DROP COLUMN depth,
+    DROP COLUMN parent_id;
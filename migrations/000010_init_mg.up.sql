@@ -0,0 +1,6 @@
+ALTER TABLE comment
+    ADD COLUMN parent_id BIGINT UNSIGNED NULL,
+    ADD COLUMN depth INT NOT NULL DEFAULT 0,
+    ADD CONSTRAINT fk_comment_parent FOREIGN KEY (parent_id) REFERENCES comment(id);
+
+CREATE INDEX idx_parent_created_at ON comment(parent_id, created_at);
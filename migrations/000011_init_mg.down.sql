@@ -0,0 +1,11 @@
+ALTER TABLE comment_reaction
+    ADD UNIQUE INDEX reactor_id (reactor_id),
+    ADD UNIQUE INDEX comment_id (comment_id);
+DROP INDEX idx_comment ON comment_reaction;
+DROP INDEX idx_reactor_comment ON comment_reaction;
+
+ALTER TABLE post_reaction
+    ADD UNIQUE INDEX reactor_id (reactor_id),
+    ADD UNIQUE INDEX post_id (post_id);
+DROP INDEX idx_post ON post_reaction;
+DROP INDEX idx_reactor_post ON post_reaction;
@@ -0,0 +1,11 @@
+CREATE UNIQUE INDEX idx_reactor_post ON post_reaction(reactor_id, post_id);
+CREATE INDEX idx_post ON post_reaction(post_id);
+ALTER TABLE post_reaction
+    DROP INDEX reactor_id,
+    DROP INDEX post_id;
+
+CREATE UNIQUE INDEX idx_reactor_comment ON comment_reaction(reactor_id, comment_id);
+CREATE INDEX idx_comment ON comment_reaction(comment_id);
+ALTER TABLE comment_reaction
+    DROP INDEX reactor_id,
+    DROP INDEX comment_id;
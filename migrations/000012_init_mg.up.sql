@@ -0,0 +1,49 @@
+CREATE TABLE IF NOT EXISTS role (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    created_at DATETIME NOT NULL DEFAULT NOW(),
+    name VARCHAR(50) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS permission (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    created_at DATETIME NOT NULL DEFAULT NOW(),
+    name VARCHAR(50) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS role_permission (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+
+    role_id BIGINT UNSIGNED NOT NULL,
+    permission_id BIGINT UNSIGNED NOT NULL,
+    FOREIGN KEY (role_id) REFERENCES role(id),
+    FOREIGN KEY (permission_id) REFERENCES permission(id)
+);
+
+CREATE TABLE IF NOT EXISTS user_role (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    created_at DATETIME NOT NULL DEFAULT NOW(),
+
+    user_id BIGINT UNSIGNED NOT NULL,
+    role_id BIGINT UNSIGNED NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES user(id),
+    FOREIGN KEY (role_id) REFERENCES role(id)
+);
+
+CREATE UNIQUE INDEX idx_role_permission ON role_permission(role_id, permission_id);
+CREATE UNIQUE INDEX idx_user_role ON user_role(user_id, role_id);
+
+INSERT INTO role (name)
+VALUES
+    ("ADMIN");
+
+INSERT INTO permission (name)
+VALUES
+    ("users:manage"),
+    ("emojis:write"),
+    ("roles:manage");
+
+INSERT INTO role_permission (role_id, permission_id)
+SELECT r.id, p.id
+FROM role r
+CROSS JOIN permission p
+WHERE r.name = "ADMIN";
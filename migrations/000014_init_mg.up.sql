@@ -0,0 +1,14 @@
+ALTER TABLE user ADD COLUMN email_verified_at DATETIME DEFAULT NULL;
+
+UPDATE user SET email_verified_at = created_at;
+
+CREATE TABLE IF NOT EXISTS email_verification (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    created_at DATETIME NOT NULL DEFAULT NOW(),
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    expires_at DATETIME NOT NULL,
+    used_at DATETIME DEFAULT NULL,
+
+    user_id BIGINT UNSIGNED NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES user(id)
+);
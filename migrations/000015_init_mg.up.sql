@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS totp (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    created_at DATETIME NOT NULL DEFAULT NOW(),
+    secret VARCHAR(64) NOT NULL,
+    enabled_at DATETIME DEFAULT NULL,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+
+    user_id BIGINT UNSIGNED NOT NULL UNIQUE,
+    FOREIGN KEY (user_id) REFERENCES user(id)
+);
+
+CREATE TABLE IF NOT EXISTS recovery_code (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    created_at DATETIME NOT NULL DEFAULT NOW(),
+    code_hash CHAR(64) NOT NULL,
+    used_at DATETIME DEFAULT NULL,
+
+    user_id BIGINT UNSIGNED NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES user(id)
+);
+
+CREATE INDEX idx_user_code_hash ON recovery_code(user_id, code_hash);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS webauthn_session;
+DROP TABLE IF EXISTS webauthn_credential;
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS webauthn_credential (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    created_at DATETIME NOT NULL DEFAULT NOW(),
+    name VARCHAR(100) NOT NULL,
+    credential_id VARBINARY(1023) NOT NULL UNIQUE,
+    public_key BLOB NOT NULL,
+    attestation_type VARCHAR(32) NOT NULL DEFAULT '',
+    transport VARCHAR(255) NOT NULL DEFAULT '',
+    aaguid VARBINARY(16) DEFAULT NULL,
+    sign_count INT UNSIGNED NOT NULL DEFAULT 0,
+    clone_warning BOOLEAN NOT NULL DEFAULT FALSE,
+    backup_eligible BOOLEAN NOT NULL DEFAULT FALSE,
+    backup_state BOOLEAN NOT NULL DEFAULT FALSE,
+    last_used_at DATETIME DEFAULT NULL,
+
+    user_id BIGINT UNSIGNED NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES user(id)
+);
+
+CREATE TABLE IF NOT EXISTS webauthn_session (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    created_at DATETIME NOT NULL DEFAULT NOW(),
+    session_hash CHAR(64) NOT NULL UNIQUE,
+    ceremony ENUM('REGISTRATION', 'LOGIN') NOT NULL,
+    data JSON NOT NULL,
+    expires_at DATETIME NOT NULL,
+
+    user_id BIGINT UNSIGNED DEFAULT NULL,
+    FOREIGN KEY (user_id) REFERENCES user(id)
+);
@@ -0,0 +1,7 @@
+DROP INDEX idx_provider_type_provider_id ON user_social;
+
+ALTER TABLE user_social
+    MODIFY provider_id VARCHAR(50) NOT NULL,
+    ADD UNIQUE INDEX provider_id (provider_id);
+
+CREATE UNIQUE INDEX idx_provider_id ON user_social(provider_id);
@@ -0,0 +1,6 @@
+ALTER TABLE user_social
+    DROP INDEX provider_id,
+    DROP INDEX idx_provider_id,
+    MODIFY provider_id VARCHAR(255) NOT NULL;
+
+CREATE UNIQUE INDEX idx_provider_type_provider_id ON user_social(provider_type_id, provider_id);
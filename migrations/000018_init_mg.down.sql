@@ -0,0 +1,5 @@
+DROP INDEX idx_family_id ON refresh_token;
+
+ALTER TABLE refresh_token
+    DROP COLUMN revoked_reason,
+    DROP COLUMN family_id;
@@ -0,0 +1,7 @@
+ALTER TABLE refresh_token
+    ADD COLUMN family_id CHAR(36) NOT NULL DEFAULT '',
+    ADD COLUMN revoked_reason VARCHAR(25) DEFAULT NULL;
+
+UPDATE refresh_token SET family_id = token WHERE family_id = '';
+
+CREATE INDEX idx_family_id ON refresh_token(family_id);
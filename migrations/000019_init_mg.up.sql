@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS session (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    created_at DATETIME NOT NULL DEFAULT NOW(),
+    last_used_at DATETIME NOT NULL DEFAULT NOW(),
+    family_id CHAR(36) NOT NULL UNIQUE,
+    device_name VARCHAR(100) NOT NULL DEFAULT '',
+    user_agent VARCHAR(255) NOT NULL DEFAULT '',
+    ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    login_method VARCHAR(50) NOT NULL DEFAULT '',
+    user_id BIGINT UNSIGNED NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES user(id)
+);
+
+CREATE INDEX idx_user_last_used_at ON session(user_id, last_used_at);
+
+INSERT INTO session(created_at, last_used_at, family_id, user_id)
+SELECT MIN(created_at), MAX(created_at), family_id, user_id
+FROM refresh_token
+GROUP BY family_id, user_id;
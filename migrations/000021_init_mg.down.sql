@@ -0,0 +1,7 @@
+DELETE rp FROM role_permission rp
+JOIN permission p ON p.id = rp.permission_id
+WHERE p.name = "audit:read";
+
+DELETE FROM permission WHERE name = "audit:read";
+
+DROP TABLE IF EXISTS audit_event;
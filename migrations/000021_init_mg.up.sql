@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS audit_event (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    created_at DATETIME NOT NULL DEFAULT NOW(),
+    action VARCHAR(50) NOT NULL,
+    actor_id BIGINT UNSIGNED DEFAULT NULL,
+    target_id BIGINT UNSIGNED DEFAULT NULL,
+    ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent VARCHAR(255) NOT NULL DEFAULT '',
+    metadata JSON DEFAULT NULL
+);
+
+CREATE INDEX idx_target_created_at ON audit_event(target_id, created_at);
+CREATE INDEX idx_actor_created_at ON audit_event(actor_id, created_at);
+CREATE INDEX idx_action_created_at ON audit_event(action, created_at);
+
+INSERT INTO permission (name)
+VALUES
+    ("audit:read");
+
+INSERT INTO role_permission (role_id, permission_id)
+SELECT r.id, p.id
+FROM role r
+CROSS JOIN permission p
+WHERE r.name = "ADMIN" AND p.name = "audit:read";
@@ -0,0 +1 @@
+ALTER TABLE user MODIFY password VARCHAR(100) DEFAULT "";
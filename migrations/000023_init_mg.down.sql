@@ -0,0 +1,4 @@
+ALTER TABLE totp
+    DROP COLUMN failed_attempts,
+    DROP COLUMN locked_until,
+    DROP COLUMN challenges_revoked_at;
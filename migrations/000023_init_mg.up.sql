@@ -0,0 +1,4 @@
+ALTER TABLE totp
+    ADD COLUMN failed_attempts INT NOT NULL DEFAULT 0,
+    ADD COLUMN locked_until DATETIME DEFAULT NULL,
+    ADD COLUMN challenges_revoked_at DATETIME DEFAULT NULL;
@@ -0,0 +1,7 @@
+DROP INDEX idx_user_low_high ON friendship;
+
+ALTER TABLE friendship
+    DROP INDEX idx_requester_addressee,
+    ADD UNIQUE INDEX idx_requester_addressee (requester_id, addressee_id),
+    DROP COLUMN user_low_id,
+    DROP COLUMN user_high_id;
@@ -0,0 +1,12 @@
+DELETE f1 FROM friendship f1
+JOIN friendship f2 ON f1.requester_id = f2.addressee_id AND f1.addressee_id = f2.requester_id
+WHERE (f2.status = 'ACCEPTED' AND f1.status != 'ACCEPTED')
+OR ((f1.status = 'ACCEPTED') = (f2.status = 'ACCEPTED') AND f1.id > f2.id);
+
+ALTER TABLE friendship
+    ADD COLUMN user_low_id BIGINT UNSIGNED AS (LEAST(requester_id, addressee_id)) STORED,
+    ADD COLUMN user_high_id BIGINT UNSIGNED AS (GREATEST(requester_id, addressee_id)) STORED,
+    DROP INDEX idx_requester_addressee,
+    ADD INDEX idx_requester_addressee (requester_id, addressee_id);
+
+CREATE UNIQUE INDEX idx_user_low_high ON friendship(user_low_id, user_high_id);
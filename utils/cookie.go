@@ -43,6 +43,47 @@ func ClearTokens(ctx *gin.Context) {
 	clearRefreshToken(ctx)
 }
 
+// SetChallenge is used by social logins since the challenge cannot be returned in the body of a redirect
+func SetChallenge(ctx *gin.Context, value string) {
+	expirationInSeconds := (5 * time.Minute).Seconds()
+	ctx.SetCookie("challenge", value, int(expirationInSeconds), "/", "", secure, httpOnly)
+	ctx.SetSameSite(http.SameSiteStrictMode)
+}
+
+func ClearChallenge(ctx *gin.Context) {
+	ctx.SetCookie(
+		"challenge", // cookie name
+		"",          // value
+		-1,          // maxAge negative to delete
+		"/",         // path
+		"",          // domain (empty = current domain)
+		secure,      // secure
+		httpOnly,    // httpOnly
+	)
+}
+
+// SetOAuthState uses lax same site since the provider redirects back to the callback from another site
+// gin only applies same site to cookies set after calling SetSameSite so it's restored to strict after
+func SetOAuthState(ctx *gin.Context, value string, maxAge int) {
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie("oauthState", value, maxAge, "/auth", "", secure, httpOnly)
+	ctx.SetSameSite(http.SameSiteStrictMode)
+}
+
+func ClearOAuthState(ctx *gin.Context) {
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(
+		"oauthState", // cookie name
+		"",           // value
+		-1,           // maxAge negative to delete
+		"/auth",      // path
+		"",           // domain (empty = current domain)
+		secure,       // secure
+		httpOnly,     // httpOnly
+	)
+	ctx.SetSameSite(http.SameSiteStrictMode)
+}
+
 func setRefreshToken(ctx *gin.Context, value string) error {
 	expirationInDays, err := strconv.Atoi(os.Getenv("REFRESH_TOKEN_EXPIRATION_IN_DAYS"))
 	if err != nil {
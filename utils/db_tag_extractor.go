@@ -31,7 +31,7 @@ func extractDatabaseTags(i interface{}) []string {
 	for i := 0; i < val.NumField(); i++ {
 		field := val.Field(i)
 		dbTag := field.Tag.Get("db")
-		if dbTag != "" {
+		if dbTag != "" && dbTag != "-" {
 			tags = append(tags, dbTag)
 		}
 	}
@@ -1,14 +1,18 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
 
 	"os"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 )
 
+// duplicateEntry is the MySQL error number for unique key violations
+const duplicateEntry = 1062
+
 func InitMySQLConnection() (*sqlx.DB, error) {
 	// dsn Data Source Name
 	dsn := fmt.Sprintf(
@@ -27,3 +31,13 @@ func InitMySQLConnection() (*sqlx.DB, error) {
 
 	return db, nil
 }
+
+// IsDuplicateEntry reports whether err is caused by a unique key violation
+func IsDuplicateEntry(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == duplicateEntry
+	}
+
+	return false
+}
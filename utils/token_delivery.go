@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IsTokenInBody non-browser clients like the mobile app and CLI tools send X-Token-Delivery: body
+// to receive and send the tokens in the body instead of cookies
+func IsTokenInBody(ctx *gin.Context) bool {
+	return strings.EqualFold(ctx.GetHeader("X-Token-Delivery"), "body")
+}
+
+// SendTokens sets the cookies for browsers or returns the tokens in the body for non-browser clients
+func SendTokens(ctx *gin.Context, accessToken, refreshToken, message string) error {
+	if !IsTokenInBody(ctx) {
+		err := SetTokens(ctx, accessToken, refreshToken)
+		if err != nil {
+			return err
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message": message,
+		})
+		return nil
+	}
+
+	expirationInMinute, err := strconv.Atoi(os.Getenv("JWT_EXPIRATION_IN_MINUTE"))
+	if err != nil {
+		return err
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":       message,
+		"token_type":    "Bearer",
+		"access_token":  accessToken,
+		"expires_in":    int((time.Duration(expirationInMinute) * time.Minute).Seconds()),
+		"refresh_token": refreshToken,
+	})
+	return nil
+}
+
+// GetRefreshToken reads the refresh_token in the body for non-browser clients or the refreshToken cookie for browsers
+func GetRefreshToken(ctx *gin.Context) (string, error) {
+	if !IsTokenInBody(ctx) {
+		return ctx.Cookie("refreshToken")
+	}
+
+	request := struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}{}
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return "", errors.New("refresh_token is required")
+	}
+
+	return request.RefreshToken, nil
+}
+
+// GetCurrentRefreshToken reads the X-Refresh-Token header for non-browser clients or the refreshToken cookie for browsers
+// for requests without a body like listing the sessions, it's empty when there's none
+func GetCurrentRefreshToken(ctx *gin.Context) string {
+	if IsTokenInBody(ctx) {
+		return strings.TrimSpace(ctx.GetHeader("X-Refresh-Token"))
+	}
+
+	refreshToken, _ := ctx.Cookie("refreshToken")
+	return refreshToken
+}